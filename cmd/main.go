@@ -9,7 +9,7 @@ import (
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatal("Please provide a command: http | superuser")
+		log.Fatal("Please provide a command: http | superuser | outbox-relay")
 	}
 
 	cmd := os.Args[1]
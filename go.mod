@@ -4,6 +4,7 @@ go 1.25.5
 
 require (
 	github.com/golang-jwt/jwt/v4 v4.5.2
+	github.com/gorilla/websocket v1.5.3
 	github.com/ilyakaznacheev/cleanenv v1.5.0
 	github.com/lib/pq v1.11.1
 	github.com/minio/minio-go/v7 v7.0.98
@@ -2,21 +2,31 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/Jaxongir1006/Chat-X-v2/internal/config"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/eventbus"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/localstore"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/logger"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/mailer"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/minio"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres"
 	adminRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/admin"
 	authRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/auth"
 	chatRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/chat"
+	mediaRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/media"
+	outboxRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/outbox"
 	sessionInfra "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/session"
 	userInfra "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/user"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/uow"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/ratelimit"
 	redisInfra "github.com/Jaxongir1006/Chat-X-v2/internal/infra/redis"
+	redisBus "github.com/Jaxongir1006/Chat-X-v2/internal/infra/redis/bus"
+	redisLimiter "github.com/Jaxongir1006/Chat-X-v2/internal/infra/redis/ratelimit"
 	redisStore "github.com/Jaxongir1006/Chat-X-v2/internal/infra/redis/store"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/security"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/server"
@@ -25,13 +35,17 @@ import (
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/media"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/session"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/storage"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/user"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/ws"
 	adminUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/admin"
 	authUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/auth"
 	chatUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/chat"
 	mediaUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/media"
+	outboxUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/outbox"
 	sessionUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/session"
 	userUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/user"
+	"github.com/rs/zerolog"
 )
 
 func Run(cmd string) {
@@ -41,6 +55,9 @@ func Run(cmd string) {
 	if cmd == "superuser" {
 		createSuperuser()
 	}
+	if cmd == "outbox-relay" {
+		runOutboxRelay()
+	}
 }
 
 func runHttp() {
@@ -79,15 +96,10 @@ func runHttp() {
 		}
 	}()
 
-	// init minio
-	minioStore, err := minio.New(cfg.MinioConfig)
+	// init object storage
+	objectStore, storageHandler, err := newObjectStorage(ctx, cfg, logger)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to initialized minio")
-		return
-	}
-
-	if err := minioStore.EnsureBucket(ctx); err != nil {
-		logger.Fatal().Err(err).Msg("failed to ensure minio bucket")
+		logger.Fatal().Err(err).Msg("failed to initialize object storage")
 		return
 	}
 
@@ -96,9 +108,18 @@ func runHttp() {
 	sessionRepo := sessionInfra.NewSessionRepo(dbPool.DB, logger)
 	userRepo := userInfra.NewUserRepo(dbPool.DB, logger)
 	chatRepo := chatRepo.NewChatRepo(dbPool.DB, logger)
+	outboxRepo := outboxRepo.NewOutboxRepo(dbPool.DB, logger)
+	mediaRepo := mediaRepo.NewMediaRepo(dbPool.DB, logger)
 
 	// init middlewares
 	authMiddleware := middleware.NewAuthMiddleware(sessionRepo)
+	proxies, err := middleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse trusted proxies")
+		return
+	}
+	limiter := ratelimit.NewFallbackLimiter(redisLimiter.NewRedisLimiter(redisPool.Client), ratelimit.NewMemoryLimiter(), logger)
+	rateLimiter := middleware.NewRateLimiter(limiter, cfg.RateLimit, logger)
 
 	// init uow
 	uow := uow.NewSQLUnitOfWork(dbPool.DB)
@@ -107,14 +128,49 @@ func runHttp() {
 	hasher := security.NewBcryptHasher(10)
 	codeHasher := security.NewHMACHasher("secret")
 	redis := redisStore.NewOTPRedisStore(redisPool.Client)
+	uploadIntents := redisStore.NewUploadIntentRedisStore(redisPool.Client)
 	tokenSrv := security.NewToken(cfg.TokenConfig)
 
+	// init mailer
+	mail, err := newMailer(cfg.MailerConfig, cfg.AppMode, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize mailer")
+		return
+	}
+	mailQueue := mailer.NewQueue(mail, logger)
+	go mailQueue.Run(ctx)
+
+	// init event bus and realtime hub
+	var bus eventbus.Bus
+	if cfg.EventBusConfig.Driver == config.EventBusMemory {
+		bus = eventbus.NewMemoryBus()
+	} else {
+		bus = redisBus.NewRedisBus(redisPool.Client, logger)
+	}
+	defer func() {
+		if err := bus.Close(); err != nil {
+			logger.Error().Err(err).Msg("failed to close event bus")
+		}
+	}()
+
+	hub := ws.NewHub(bus, logger)
+	defer hub.Close()
+
+	go func() {
+		if err := bus.Run(ctx, hub.Dispatch); err != nil && ctx.Err() == nil {
+			logger.Error().Err(err).Msg("event bus stopped")
+		}
+	}()
+
 	// init usecases
-	authUsecase := authUsecase.NewAuthUsecase(authRepo, sessionRepo, redis, tokenSrv, hasher, logger, codeHasher, uow)
+	authUsecase := authUsecase.NewAuthUsecase(authRepo, sessionRepo, redis, tokenSrv, hasher, logger, codeHasher, uow, mailQueue)
 	sessionUsecase := sessionUsecase.NewSessionService(sessionRepo, tokenSrv, 5)
-	userUsecase := userUsecase.NewUserUsecase(userRepo, sessionRepo, uow, hasher, logger)
-	mediaUsecase := mediaUsecase.NewMediaUsecase(minioStore, logger)
-	chatUsecase := chatUsecase.NewChatUsecase(chatRepo, uow, logger)
+	userUsecase := userUsecase.NewUserUsecase(userRepo, sessionRepo, mediaRepo, objectStore, uow, hasher, logger)
+	mediaUsecase := mediaUsecase.NewMediaUsecase(objectStore, mediaRepo, uploadIntents, uow, cfg.MediaConfig, logger)
+	chatUsecase := chatUsecase.NewChatUsecase(chatRepo, mediaRepo, outboxRepo, uow, bus, objectStore, cfg.ChatConfig, logger)
+
+	go mediaUsecase.RunUploadCleanup(ctx, 10*time.Minute)
+	go mediaUsecase.RunImageProcessing(ctx, 5*time.Second)
 
 	// init handlers
 	authHandler := auth.NewAuthHandler(authUsecase, logger)
@@ -122,9 +178,10 @@ func runHttp() {
 	userHandler := user.NewUserHandler(userUsecase, logger)
 	mediaHandler := media.NewMediaHandler(mediaUsecase, logger)
 	chatHandler := chat.NewChatHandler(chatUsecase, logger)
-	
+	wsHandler := ws.NewWSHandler(hub, chatUsecase, sessionUsecase, logger)
+
 	// init server
-	srv := server.NewServer(cfg.Server, authMiddleware, logger, authHandler, sessionHandler, userHandler, mediaHandler, chatHandler)
+	srv := server.NewServer(cfg.Server, proxies, authMiddleware, rateLimiter, logger, authHandler, sessionHandler, userHandler, mediaHandler, chatHandler, storageHandler, wsHandler)
 
 	// start server async
 	go func() {
@@ -177,3 +234,99 @@ func createSuperuser() {
 		log.Fatalf("Failed to create superuser: %v", err)
 	}
 }
+
+func runOutboxRelay() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger := logger.New(cfg.AppMode)
+
+	ctx := waitForShutdown()
+
+	dbPool, err := postgres.New(cfg.PostgresConfig)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize postgres")
+		return
+	}
+	defer func() {
+		if err := dbPool.Close(); err != nil {
+			logger.Error().Err(err).Msg("failed to close db pool")
+		}
+	}()
+
+	outboxRepo := outboxRepo.NewOutboxRepo(dbPool.DB, logger)
+	uow := uow.NewSQLUnitOfWork(dbPool.DB)
+
+	relay := outboxUsecase.NewOutboxRelay(outboxRepo, uow, cfg.KafkaConfig, 100, time.Second, logger)
+	defer func() {
+		if err := relay.Close(); err != nil {
+			logger.Error().Err(err).Msg("failed to close kafka producers")
+		}
+	}()
+
+	relay.Run(ctx)
+}
+
+// newObjectStorage builds the storage selected by the storage driver. The local drivers
+// also need the handler that serves their signed URLs.
+func newObjectStorage(ctx context.Context, cfg *config.Config, logger zerolog.Logger) (minio.ObjectStorage, *storage.StorageHandler, error) {
+	sc := cfg.StorageConfig
+
+	switch sc.Driver {
+	case "", config.StorageMinio:
+		store, err := minio.New(cfg.MinioConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := store.EnsureBucket(ctx); err != nil {
+			return nil, nil, err
+		}
+		return store, nil, nil
+
+	case config.StorageFilesystem, config.StorageMemory:
+		if sc.PublicURL == "" {
+			sc.PublicURL = fmt.Sprintf("http://%s:%d", cfg.Server.Host, cfg.Server.Port)
+		}
+		key := []byte(sc.SigningKey)
+		if len(key) == 0 {
+			key = make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return nil, nil, err
+			}
+			logger.Warn().Msg("STORAGE_SIGNING_KEY is not set, signed URLs will not survive a restart")
+		}
+		signer := localstore.NewSigner(sc.PublicURL, key)
+
+		var store *localstore.Storage
+		if sc.Driver == config.StorageMemory {
+			store = localstore.NewMemory(signer)
+		} else {
+			if sc.Dir == "" {
+				sc.Dir = "data/storage"
+			}
+			var err error
+			if store, err = localstore.NewFilesystem(sc.Dir, signer); err != nil {
+				return nil, nil, err
+			}
+		}
+		return store, storage.NewStorageHandler(store, logger), nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown storage driver %q", sc.Driver)
+}
+
+func newMailer(cfg config.MailerConfig, appMode string, logger zerolog.Logger) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "", config.MailerSMTP:
+		return mailer.NewSMTPMailer(cfg)
+	case config.MailerConsole:
+		// nothing would ever reach the users
+		if appMode == config.ProdMode {
+			return nil, fmt.Errorf("mailer driver %q cannot be used in production", cfg.Driver)
+		}
+		return mailer.NewDevMailer(cfg.Dir, cfg.From, logger)
+	}
+	return nil, fmt.Errorf("unknown mailer driver %q", cfg.Driver)
+}
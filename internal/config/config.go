@@ -34,12 +34,21 @@ type Config struct {
 	RedisConfig    RedisConfig
 	KafkaConfig    KafkaConfig
 	MinioConfig    MinioConfig
-	TokenConfig    TokenConfig `yaml:"token"`
+	StorageConfig  StorageConfig   `yaml:"storage"`
+	MailerConfig   MailerConfig    `yaml:"mailer"`
+	RateLimit      RateLimitConfig `yaml:"rate_limit"`
+	TokenConfig    TokenConfig     `yaml:"token"`
+	EventBusConfig EventBusConfig  `yaml:"event_bus"`
+	ChatConfig     ChatConfig      `yaml:"chat"`
+	MediaConfig    MediaConfig     `yaml:"media"`
 }
 
 type Server struct {
 	Host string `yaml:"host" default:"localhost"`
 	Port int    `yaml:"port" default:"8080"`
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies in front of
+	// the server. Forwarding headers are only believed when they come from one of them.
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type PostgresConfig struct {
@@ -87,6 +96,89 @@ type MinioConfig struct {
 	PresignExpirySeconds int    `env:"MINIO_PRESIGN_EXPIRY" default:"3600"`
 }
 
+const (
+	StorageMinio      = "minio"
+	StorageFilesystem = "filesystem"
+	StorageMemory     = "memory"
+)
+
+// StorageConfig selects where media objects are kept: "minio" uses MinioConfig,
+// "filesystem" keeps them under Dir and "memory" loses them on restart. The last two
+// serve their signed URLs through the API server itself, under PublicURL.
+type StorageConfig struct {
+	Driver string `yaml:"driver" env:"STORAGE_DRIVER" default:"minio"`
+	Dir    string `yaml:"dir" env:"STORAGE_DIR" default:"data/storage"`
+
+	// PublicURL is the base of the signed URLs handed to clients, e.g. https://api.example.com.
+	PublicURL string `yaml:"public_url" env:"STORAGE_PUBLIC_URL"`
+	// SigningKey signs the URLs; it must be shared by all instances.
+	SigningKey string `yaml:"signing_key" env:"STORAGE_SIGNING_KEY"`
+}
+
+const (
+	MailerSMTP    = "smtp"
+	MailerConsole = "console"
+)
+
+// MailerConfig selects how email is delivered: "smtp" sends it through Host, "console"
+// only logs that a message was sent and, when Dir is set, writes it there as .eml files.
+// The console driver is refused in production.
+type MailerConfig struct {
+	Driver   string `yaml:"driver" env:"MAILER_DRIVER" default:"smtp"`
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT" default:"587"`
+	User     string `env:"SMTP_USER"`
+	Password string `env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" env:"MAILER_FROM"` // "Chat-X <no-reply@example.com>"
+	Dir      string `yaml:"dir" env:"MAILER_DIR"`
+}
+
+const (
+	EventBusRedis  = "redis"
+	EventBusMemory = "memory"
+)
+
+// EventBusConfig selects how realtime chat events reach the WebSocket hubs:
+// "redis" fans them out across instances, "memory" only works with a single instance.
+type EventBusConfig struct {
+	Driver string `yaml:"driver" env:"EVENT_BUS_DRIVER" default:"redis"`
+}
+
+type ChatConfig struct {
+	// EditWindow is how long after sending a message its sender may still edit it.
+	EditWindow time.Duration `yaml:"edit_window" env:"CHAT_EDIT_WINDOW" default:"48h"`
+
+	// AllowedReactions restricts reactions to a fixed set of emoji.
+	// When empty any single emoji or character is accepted.
+	AllowedReactions []string `yaml:"allowed_reactions" env:"CHAT_ALLOWED_REACTIONS" env-separator:","`
+}
+
+type MediaConfig struct {
+	// UploadPolicies holds the upload policy of each purpose (avatar, message_attachment,
+	// group_photo). Purposes left out use the built in defaults.
+	UploadPolicies map[string]UploadPolicy `yaml:"upload_policies"`
+}
+
+// UploadPolicy limits what can be uploaded for one purpose. Types are matched against
+// the type detected from the content, not the one the client declares.
+type UploadPolicy struct {
+	AllowedTypes []string `yaml:"allowed_types"` // exact types or wildcards such as image/* and */*
+	MaxSize      int64    `yaml:"max_size"`      // bytes
+	MaxWidth     int      `yaml:"max_width"`     // pixels, images only
+	MaxHeight    int      `yaml:"max_height"`
+}
+
+type RateLimitConfig struct {
+	// Rules holds the limit of each route group (login, register, verify, send_message,
+	// upload). Groups left out use the built in defaults.
+	Rules map[string]RateLimitRule `yaml:"rules"`
+}
+
+type RateLimitRule struct {
+	Limit  int           `yaml:"limit"`  // requests per window
+	Window time.Duration `yaml:"window"` // sliding
+}
+
 type TokenConfig struct {
 	AccessSecret  string        `yaml:"access_secret"`
 	RefreshSecret string        `yaml:"refresh_secret"`
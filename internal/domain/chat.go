@@ -21,6 +21,26 @@ const (
 	ParticipantRoleLeft       ParticipantRole = "left"
 )
 
+// Restriction limits what a restricted participant may send.
+type Restriction string
+
+const (
+	RestrictionMessages Restriction = "messages" // nothing at all
+	RestrictionMedia    Restriction = "media"
+	RestrictionLinks    Restriction = "links"
+)
+
+type ModerationActionType string
+
+const (
+	ModerationBan        ModerationActionType = "ban"
+	ModerationUnban      ModerationActionType = "unban"
+	ModerationRestrict   ModerationActionType = "restrict"
+	ModerationUnrestrict ModerationActionType = "unrestrict"
+	ModerationMute       ModerationActionType = "mute"
+	ModerationUnmute     ModerationActionType = "unmute"
+)
+
 type MessageType string
 
 const (
@@ -42,21 +62,68 @@ type Conversation struct {
 	IsPublic      bool             `json:"is_public"`
 	CreatedBy     uint64           `json:"created_by"`
 	LastMessageID *uint64          `json:"last_message_id,omitempty"`
+	MemberCount   int64            `json:"member_count"`
 	CreatedAt     time.Time        `json:"created_at"`
 	UpdatedAt     time.Time        `json:"updated_at"`
 }
 
 type Message struct {
-	ID             uint64      `json:"id"`
-	ConversationID uint64      `json:"conversation_id"`
-	SenderID       *uint64     `json:"sender_id,omitempty"`
-	Type           MessageType `json:"type"`
-	Text           *string     `json:"text,omitempty"`
-	CreatedAt      time.Time   `json:"created_at"`
-	EditedAt       *time.Time  `json:"edited_at,omitempty"`
-	ReplyToID      *uint64     `json:"reply_to_id,omitempty"`
-	ForwardFromID  *uint64     `json:"forward_from_id,omitempty"`
-	DeletedAt      *time.Time  `json:"deleted_at,omitempty"`
+	ID              uint64      `json:"id"`
+	ConversationID  uint64      `json:"conversation_id"`
+	SenderID        *uint64     `json:"sender_id,omitempty"`
+	Type            MessageType `json:"type"`
+	Text            *string     `json:"text,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	EditedAt        *time.Time  `json:"edited_at,omitempty"`
+	ReplyToID       *uint64     `json:"reply_to_id,omitempty"`
+	ForwardFromID   *uint64     `json:"forward_from_id,omitempty"`
+	ForwardSenderID *uint64     `json:"forward_sender_id,omitempty"` // sender of the original, kept at forward time
+	DeletedAt       *time.Time  `json:"deleted_at,omitempty"`
+}
+
+// Attachment links an uploaded object to a message. Only the storage key is kept;
+// download URLs are presigned when the message is read.
+type Attachment struct {
+	ID         uint64    `json:"id"`
+	MessageID  uint64    `json:"message_id"`
+	StorageKey string    `json:"storage_key"`
+	MimeType   *string   `json:"mime_type,omitempty"`
+	SizeBytes  *int64    `json:"size_bytes,omitempty"`
+	Width      *int      `json:"width,omitempty"`
+	Height     *int      `json:"height,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MessageEdit is one revision of an edited message, holding the text it replaced.
+type MessageEdit struct {
+	ID        uint64    `json:"id"`
+	MessageID uint64    `json:"message_id"`
+	EditorID  uint64    `json:"editor_id"`
+	OldText   *string   `json:"old_text,omitempty"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+type Reaction struct {
+	MessageID uint64    `json:"message_id"`
+	UserID    uint64    `json:"user_id"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReactionCount aggregates the reactions of a message with one emoji. Reacted tells
+// whether the viewing user is among them.
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+// ReadReceipt tells that a user has read a conversation up to and including a message.
+type ReadReceipt struct {
+	ConversationID uint64    `json:"conversation_id"`
+	UserID         uint64    `json:"user_id"`
+	MessageID      uint64    `json:"message_id"`
+	ReadAt         time.Time `json:"read_at"`
 }
 
 type Participant struct {
@@ -68,4 +135,77 @@ type Participant struct {
 	MutedUntil        *time.Time      `json:"muted_until,omitempty"`
 	IsPinned          bool            `json:"is_pinned"`
 	LastReadMessageID *uint64         `json:"last_read_message_id,omitempty"`
+	BannedUntil       *time.Time      `json:"banned_until,omitempty"`
+	Restrictions      []Restriction   `json:"restrictions,omitempty"`
+	RestrictedUntil   *time.Time      `json:"restricted_until,omitempty"`
+}
+
+// Banned reports whether the participant is banned at t. Expired bans no longer keep
+// the user out, although the role stays banned until they rejoin.
+func (p *Participant) Banned(t time.Time) bool {
+	return p.Role == ParticipantRoleBanned && (p.BannedUntil == nil || p.BannedUntil.After(t))
+}
+
+// Muted reports whether the participant may not send anything at t.
+func (p *Participant) Muted(t time.Time) bool {
+	return p.MutedUntil != nil && p.MutedUntil.After(t)
+}
+
+// Restricted reports whether r applies to the participant at t. Restrictions outlive
+// leaving and rejoining; a restricted participant without any recorded restriction may
+// not send anything.
+func (p *Participant) Restricted(r Restriction, t time.Time) bool {
+	if p.RestrictedUntil != nil && !p.RestrictedUntil.After(t) {
+		return false
+	}
+	if len(p.Restrictions) == 0 {
+		return p.Role == ParticipantRoleRestricted
+	}
+	for _, have := range p.Restrictions {
+		if have == r || have == RestrictionMessages {
+			return true
+		}
+	}
+	return false
+}
+
+// ModerationAction is an entry of a conversation's moderation log.
+type ModerationAction struct {
+	ID             uint64               `json:"id"`
+	ConversationID uint64               `json:"conversation_id"`
+	ActorID        uint64               `json:"actor_id"`
+	TargetID       uint64               `json:"target_id"`
+	Action         ModerationActionType `json:"action"`
+	Restrictions   []Restriction        `json:"restrictions,omitempty"`
+	Until          *time.Time           `json:"until,omitempty"`
+	Reason         *string              `json:"reason,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+// InviteLink lets users join a group or channel by code. A nil ExpiresAt or MaxUses
+// means no limit.
+type InviteLink struct {
+	ID               uint64     `json:"id"`
+	ConversationID   uint64     `json:"conversation_id"`
+	Code             string     `json:"code"`
+	CreatedBy        uint64     `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	MaxUses          *int       `json:"max_uses,omitempty"`
+	UsesCount        int        `json:"uses_count"`
+	IsActive         bool       `json:"is_active"`
+	RequiresApproval bool       `json:"requires_approval"`
+}
+
+// Usable reports whether the link still admits users at t.
+func (l *InviteLink) Usable(t time.Time) bool {
+	return l.IsActive && (l.ExpiresAt == nil || l.ExpiresAt.After(t)) && (l.MaxUses == nil || l.UsesCount < *l.MaxUses)
+}
+
+// JoinRequest is a user waiting for an admin to let them into a chat.
+type JoinRequest struct {
+	ConversationID uint64    `json:"conversation_id"`
+	UserID         uint64    `json:"user_id"`
+	RequestedAt    time.Time `json:"requested_at"`
+	Message        *string   `json:"message,omitempty"`
 }
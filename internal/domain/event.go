@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+type EventType string
+
+const (
+	EventMessageCreated     EventType = "message.created"
+	EventMessageUpdated     EventType = "message.updated"
+	EventMessageDeleted     EventType = "message.deleted"
+	EventMessageHidden      EventType = "message.hidden"
+	EventMessageRead        EventType = "message.read"
+	EventReactionAdded      EventType = "reaction.added"
+	EventReactionRemoved    EventType = "reaction.removed"
+	EventParticipantAdded   EventType = "participant.added"
+	EventParticipantRemoved EventType = "participant.removed"
+	EventParticipantUpdated EventType = "participant.updated"
+	EventJoinRequested      EventType = "join_request.created"
+	EventJoinApproved       EventType = "join_request.approved"
+	EventJoinDeclined       EventType = "join_request.declined"
+	EventSubscribed         EventType = "channel.subscribed"
+	EventUnsubscribed       EventType = "channel.unsubscribed"
+)
+
+// Event is a realtime chat event delivered to the participants of a conversation.
+// UserIDs lists the users whose membership changed, so gateways can start or stop
+// delivering the conversation to them, or the recipients of a user scoped event.
+// ID lets receivers drop duplicate deliveries.
+type Event struct {
+	ID             string    `json:"id"`
+	Type           EventType `json:"type"`
+	ConversationID uint64    `json:"conversation_id"`
+	UserIDs        []uint64  `json:"user_ids,omitempty"`
+	Payload        any       `json:"payload,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// UserScoped reports whether the event concerns only the users in UserIDs and must
+// not be delivered to the rest of the conversation, e.g. a message deleted "for me"
+// or a join request, which only the admins and the requester see. Channel subscribers
+// coming and going are only told to themselves.
+func (e Event) UserScoped() bool {
+	switch e.Type {
+	case EventMessageHidden, EventJoinRequested, EventJoinApproved, EventJoinDeclined, EventSubscribed, EventUnsubscribed:
+		return true
+	}
+	return false
+}
+
+// Subscribes reports whether the event starts delivering the conversation to the users
+// in UserIDs.
+func (e Event) Subscribes() bool {
+	return e.Type == EventParticipantAdded || e.Type == EventSubscribed
+}
+
+// Unsubscribes reports whether the event stops delivering the conversation to the users
+// in UserIDs.
+func (e Event) Unsubscribes() bool {
+	return e.Type == EventParticipantRemoved || e.Type == EventUnsubscribed
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+type MediaPurpose string
+
+const (
+	MediaPurposeAvatar     MediaPurpose = "avatar"
+	MediaPurposeAttachment MediaPurpose = "message_attachment"
+	MediaPurposeGroupPhoto MediaPurpose = "group_photo"
+)
+
+func (p MediaPurpose) Valid() bool {
+	switch p {
+	case MediaPurposeAvatar, MediaPurposeAttachment, MediaPurposeGroupPhoto:
+		return true
+	}
+	return false
+}
+
+// ProcessingStatus tracks the generation of thumbnails for an uploaded image.
+type ProcessingStatus string
+
+const (
+	ProcessingNone    ProcessingStatus = "none" // not an image
+	ProcessingPending ProcessingStatus = "pending"
+	ProcessingReady   ProcessingStatus = "ready"
+	ProcessingFailed  ProcessingStatus = "failed"
+)
+
+// ProcessableImage reports whether thumbnails are generated for the content type.
+func ProcessableImage(mimeType string) bool {
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// MediaVariant selects a rendition of an image. Variants are JPEGs scaled down to fit
+// their size; the original is the uploaded file with its metadata stripped.
+type MediaVariant string
+
+const (
+	VariantOriginal MediaVariant = "original"
+	VariantThumb    MediaVariant = "thumb"
+	VariantSmall    MediaVariant = "small"
+	VariantMedium   MediaVariant = "medium"
+)
+
+// VariantSizes is the bounding box, in pixels, of every generated variant.
+var VariantSizes = map[MediaVariant]int{
+	VariantThumb:  160,
+	VariantSmall:  480,
+	VariantMedium: 1280,
+}
+
+func (v MediaVariant) Valid() bool {
+	_, ok := VariantSizes[v]
+	return ok || v == VariantOriginal
+}
+
+// VariantKey is the storage key of a generated variant of an object.
+func VariantKey(storageKey string, v MediaVariant) string {
+	return fmt.Sprintf("%s_%s.jpg", strings.TrimSuffix(storageKey, path.Ext(storageKey)), v)
+}
+
+// MediaObject is an uploaded object recorded with its owner.
+type MediaObject struct {
+	ID         uint64       `json:"id"`
+	OwnerID    uint64       `json:"owner_id"`
+	StorageKey string       `json:"storage_key"`
+	Purpose    MediaPurpose `json:"purpose"`
+	MimeType   string       `json:"mime_type"`
+	SizeBytes  int64        `json:"size_bytes"`
+	Checksum   *string      `json:"checksum,omitempty"` // hex SHA-256
+	RefCount   int          `json:"ref_count"`          // profile images and attachments using the object
+	CreatedAt  time.Time    `json:"created_at"`
+
+	// set for images once processed
+	Processing  ProcessingStatus `json:"processing"`
+	Width       *int             `json:"width,omitempty"`
+	Height      *int             `json:"height,omitempty"`
+	Placeholder *string          `json:"placeholder,omitempty"` // tiny blurred JPEG as a data URI
+}
+
+// ServeKey returns the key to serve for the requested variant, falling back to the
+// original while the variants have not been generated.
+func (m *MediaObject) ServeKey(v MediaVariant) (string, MediaVariant) {
+	if _, ok := VariantSizes[v]; ok && m.Processing == ProcessingReady {
+		return VariantKey(m.StorageKey, v), v
+	}
+	return m.StorageKey, VariantOriginal
+}
+
+// UploadIntent is an upload slot handed out to a client that uploads straight to
+// object storage with a presigned URL. It is checked against the stored object
+// when the client completes the upload.
+type UploadIntent struct {
+	ID        string       `json:"id"`
+	UserID    uint64       `json:"user_id"`
+	ObjectKey string       `json:"object_key"`
+	Purpose   MediaPurpose `json:"purpose"`
+	MimeType  string       `json:"mime_type"`
+	Size      int64        `json:"size"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+type UploadStatus string
+
+const (
+	UploadStatusActive    UploadStatus = "active"
+	UploadStatusCompleted UploadStatus = "completed"
+	UploadStatusAborted   UploadStatus = "aborted"
+)
+
+// ResumableUpload is a large upload sent in fixed size chunks, each stored as one part
+// of an S3 multipart upload. Offset is the number of bytes received so far.
+type ResumableUpload struct {
+	ID          string       `json:"id"`
+	UserID      uint64       `json:"user_id"`
+	ObjectKey   string       `json:"object_key"`
+	MultipartID string       `json:"-"`
+	Purpose     MediaPurpose `json:"purpose"`
+	MimeType    string       `json:"mime_type"`
+	Size        int64        `json:"size"`
+	ChunkSize   int64        `json:"chunk_size"`
+	Offset      int64        `json:"offset"`
+	Status      UploadStatus `json:"status"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	HashState   []byte       `json:"-"` // marshalled SHA-256 state of the bytes received so far
+}
+
+type UploadPart struct {
+	UploadID   string `json:"upload_id"`
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+}
+
+// UserMediaPrefix is the storage key prefix of every object uploaded by a user,
+// so the uploader of an object can be told from its key.
+func UserMediaPrefix(userID uint64) string {
+	return fmt.Sprintf("u/%d/", userID)
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+type OutboxEvent struct {
+	ID             uint64     `json:"id"`
+	IdempotencyKey string     `json:"idempotency_key"`
+	Topic          string     `json:"topic"`
+	EventType      string     `json:"event_type"`
+	AggregateID    uint64     `json:"aggregate_id"`
+	Payload        []byte     `json:"payload"`
+	Attempts       int        `json:"attempts"`
+	LastError      *string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	PublishedAt    *time.Time `json:"published_at,omitempty"`
+}
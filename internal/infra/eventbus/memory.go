@@ -0,0 +1,81 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
+
+// MemoryBus is an in-process Bus for tests and single-instance deployments.
+type MemoryBus struct {
+	mu         sync.RWMutex
+	subscribed map[string]struct{}
+	events     chan domain.Event
+	closeOnce  sync.Once
+	done       chan struct{}
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		subscribed: make(map[string]struct{}),
+		events:     make(chan domain.Event, 1024),
+		done:       make(chan struct{}),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, evt domain.Event) error {
+	for _, ch := range Channels(evt) {
+		b.mu.RLock()
+		_, ok := b.subscribed[ch]
+		b.mu.RUnlock()
+		if !ok {
+			continue
+		}
+
+		select {
+		case b.events <- evt:
+		case <-b.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(_ context.Context, channels ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range channels {
+		b.subscribed[ch] = struct{}{}
+	}
+	return nil
+}
+
+func (b *MemoryBus) Unsubscribe(_ context.Context, channels ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range channels {
+		delete(b.subscribed, ch)
+	}
+	return nil
+}
+
+func (b *MemoryBus) Run(ctx context.Context, handler Handler) error {
+	for {
+		select {
+		case evt := <-b.events:
+			handler(evt)
+		case <-b.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *MemoryBus) Close() error {
+	b.closeOnce.Do(func() { close(b.done) })
+	return nil
+}
@@ -0,0 +1,52 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, evt domain.Event) error
+}
+
+// Handler receives every event published to a channel the instance is subscribed to.
+// An event published to several subscribed channels is delivered once per channel.
+type Handler func(evt domain.Event)
+
+// Bus fans chat events out across instances. Each instance subscribes only to the
+// channels of the users and conversations it currently serves.
+type Bus interface {
+	Publisher
+
+	Subscribe(ctx context.Context, channels ...string) error
+	Unsubscribe(ctx context.Context, channels ...string) error
+
+	// Run delivers received events to handler until ctx is cancelled.
+	Run(ctx context.Context, handler Handler) error
+	Close() error
+}
+
+func ConversationChannel(conversationID uint64) string {
+	return fmt.Sprintf("chat:conversation:%d", conversationID)
+}
+
+func UserChannel(userID uint64) string {
+	return fmt.Sprintf("chat:user:%d", userID)
+}
+
+// Channels returns the channels an event is published to: the conversation channel,
+// plus the channels of the users whose membership changed so instances serving them
+// can start (or stop) listening to the conversation. User scoped events only go to
+// the users' channels.
+func Channels(evt domain.Event) []string {
+	var channels []string
+	if !evt.UserScoped() {
+		channels = append(channels, ConversationChannel(evt.ConversationID))
+	}
+	for _, userID := range evt.UserIDs {
+		channels = append(channels, UserChannel(userID))
+	}
+	return channels
+}
@@ -0,0 +1,163 @@
+// Package imaging decodes, orients, resizes and re-encodes uploaded images using only
+// the standard library image packages.
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+
+	// decoders for image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// ErrTooLarge is returned for images whose pixel count exceeds the limit, so that a
+// small compressed file cannot make the decoder allocate gigabytes.
+var ErrTooLarge = errors.New("image is too large")
+
+// Decode decodes a JPEG, PNG or GIF (first frame) and applies its EXIF orientation,
+// so the returned image is upright.
+func Decode(data []byte, maxPixels int) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image config: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image: %w", err)
+	}
+	if format == "jpeg" {
+		img = Orient(img, Orientation(data))
+	}
+	return img, format, nil
+}
+
+// Fit scales the image down to fit a size x size box, keeping the aspect ratio. Images
+// that already fit are only converted. Each output pixel is the average of the source
+// pixels it covers.
+func Fit(img image.Image, size int) *image.RGBA {
+	src := toRGBA(img)
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+
+	dw, dh := sw, sh
+	if sw > size || sh > size {
+		if sw >= sh {
+			dw, dh = size, sh*size/sw
+		} else {
+			dw, dh = sw*size/sh, size
+		}
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+	if dw == sw && dh == sh {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := y * sh / dh
+		y1 := max((y+1)*sh/dh, y0+1)
+		for x := 0; x < dw; x++ {
+			x0 := x * sw / dw
+			x1 := max((x+1)*sw/dw, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// Blur applies a box blur of the given radius, once horizontally and once vertically.
+func Blur(img *image.RGBA, radius int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	tmp := image.NewRGBA(image.Rect(0, 0, w, h))
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	pass := func(dst, src *image.RGBA, horizontal bool) {
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				var sum [4]int
+				n := 0
+				for d := -radius; d <= radius; d++ {
+					sx, sy := x, y
+					if horizontal {
+						sx = min(max(x+d, 0), w-1)
+					} else {
+						sy = min(max(y+d, 0), h-1)
+					}
+					p := src.Pix[sy*src.Stride+sx*4:]
+					for c := 0; c < 4; c++ {
+						sum[c] += int(p[c])
+					}
+					n++
+				}
+				i := y*dst.Stride + x*4
+				for c := 0; c < 4; c++ {
+					dst.Pix[i+c] = uint8(sum[c] / n)
+				}
+			}
+		}
+	}
+	pass(tmp, img, true)
+	pass(out, tmp, false)
+	return out
+}
+
+// EncodeJPEG encodes the image as a JPEG, flattening any transparency onto white.
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	b := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Rect, img, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("encode jpeg: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Placeholder returns a tiny blurred JPEG of the image as a data URI, small enough to
+// send inline while the real image loads.
+func Placeholder(img image.Image) (string, error) {
+	data, err := EncodeJPEG(Blur(Fit(img, 16), 1), 50)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	return dst
+}
@@ -2,20 +2,37 @@ package producer
 
 import (
 	"context"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
+const (
+	batchSize    = 500
+	batchTimeout = 10 * time.Millisecond
+)
+
 type Producer struct {
 	writer *kafka.Writer
 }
 
+// Message is a record for WriteMessages. Messages with the same key always go to the
+// same partition, so consumers see them in the order they were written.
+type Message struct {
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
 func NewProducer(broker, topic string) *Producer {
 	return &Producer{
 		writer: &kafka.Writer{
-			Addr:     kafka.TCP(broker),
-			Topic:    topic,
-			Balancer: &kafka.LeastBytes{},
+			Addr:         kafka.TCP(broker),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			BatchSize:    batchSize,
+			BatchTimeout: batchTimeout,
+			RequiredAcks: kafka.RequireAll,
 		},
 	}
 }
@@ -24,6 +41,20 @@ func (p *Producer) Write(ctx context.Context, key, value []byte) error {
 	return p.writer.WriteMessages(ctx, kafka.Message{Key: key, Value: value})
 }
 
+// WriteMessages writes the messages in one call, which returns once all of them are
+// acknowledged or failed.
+func (p *Producer) WriteMessages(ctx context.Context, msgs ...Message) error {
+	out := make([]kafka.Message, 0, len(msgs))
+	for _, m := range msgs {
+		msg := kafka.Message{Key: m.Key, Value: m.Value}
+		for k, v := range m.Headers {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		out = append(out, msg)
+	}
+	return p.writer.WriteMessages(ctx, out...)
+}
+
 func (p *Producer) Close() error {
 	return p.writer.Close()
 }
@@ -0,0 +1,148 @@
+package localstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var errBlobNotFound = errors.New("blob not found")
+
+// blobs is the raw byte store behind Storage.
+type blobs interface {
+	write(name string, r io.Reader) error
+	open(name string) (io.ReadCloser, error)
+	size(name string) (int64, error)
+	remove(name string) error
+	removePrefix(prefix string) error
+}
+
+// fsBlobs keeps every blob in a file under dir.
+type fsBlobs struct {
+	dir string
+}
+
+func (b *fsBlobs) path(name string) string {
+	return filepath.Join(b.dir, filepath.FromSlash(name))
+}
+
+// write goes through a temporary file so readers never see a half written blob.
+func (b *fsBlobs) write(name string, r io.Reader) error {
+	dst := b.path(name)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (b *fsBlobs) open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(b.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errBlobNotFound
+	}
+	return f, err
+}
+
+func (b *fsBlobs) size(name string) (int64, error) {
+	fi, err := os.Stat(b.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, errBlobNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+func (b *fsBlobs) remove(name string) error {
+	err := os.Remove(b.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (b *fsBlobs) removePrefix(prefix string) error {
+	return os.RemoveAll(b.path(prefix))
+}
+
+// memBlobs keeps every blob in memory, for tests.
+type memBlobs struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func (b *memBlobs) write(name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.blobs[name] = data
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *memBlobs) open(name string) (io.ReadCloser, error) {
+	b.mu.RLock()
+	data, ok := b.blobs[name]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, errBlobNotFound
+	}
+	// blobs are replaced, never modified in place, so the slice can be shared
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *memBlobs) size(name string) (int64, error) {
+	b.mu.RLock()
+	data, ok := b.blobs[name]
+	b.mu.RUnlock()
+	if !ok {
+		return 0, errBlobNotFound
+	}
+	return int64(len(data)), nil
+}
+
+func (b *memBlobs) remove(name string) error {
+	b.mu.Lock()
+	delete(b.blobs, name)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *memBlobs) removePrefix(prefix string) error {
+	if !strings.HasSuffix(prefix, "/") {
+		return fmt.Errorf("prefix %q must end with a slash", prefix)
+	}
+
+	b.mu.Lock()
+	for name := range b.blobs {
+		if strings.HasPrefix(name, prefix) {
+			delete(b.blobs, name)
+		}
+	}
+	b.mu.Unlock()
+	return nil
+}
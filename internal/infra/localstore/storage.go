@@ -0,0 +1,350 @@
+// Package localstore implements minio.ObjectStorage without an S3 server: on the local
+// filesystem for single node deployments and in memory for tests. Presigned URLs are
+// replaced by HMAC signed URLs served by our own storage handler.
+package localstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/minio"
+)
+
+var (
+	ErrInvalidKey     = errors.New("invalid object key")
+	ErrUploadNotFound = errors.New("multipart upload not found")
+	errSizeMismatch   = errors.New("content length does not match size")
+)
+
+// objectMeta is stored next to every object, like the metadata S3 keeps.
+type objectMeta struct {
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+}
+
+// uploadInfo describes an open multipart upload.
+type uploadInfo struct {
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+}
+
+type Storage struct {
+	blobs  blobs
+	signer *Signer
+}
+
+// NewFilesystem stores objects under dir, which is created if missing.
+func NewFilesystem(dir string, signer *Signer) (*Storage, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("storage dir is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage dir: %w", err)
+	}
+	return &Storage{blobs: &fsBlobs{dir: dir}, signer: signer}, nil
+}
+
+// NewMemory keeps objects in memory; everything is lost when the process exits.
+func NewMemory(signer *Signer) *Storage {
+	return &Storage{blobs: &memBlobs{blobs: make(map[string][]byte)}, signer: signer}
+}
+
+// Signer returns the signer the storage handler verifies URLs with.
+func (s *Storage) Signer() *Signer {
+	return s.signer
+}
+
+// EnsureBucket is a no-op, there is no bucket to create.
+func (s *Storage) EnsureBucket(ctx context.Context) error {
+	return nil
+}
+
+func (s *Storage) Upload(ctx context.Context, objectName string, file multipart.File, size int64, contentType string) (string, error) {
+	if file == nil {
+		return "", fmt.Errorf("file is nil")
+	}
+	if size <= 0 {
+		return "", fmt.Errorf("size must be > 0")
+	}
+	return s.Put(ctx, objectName, file, size, contentType)
+}
+
+// Put stores r under objectName. A non-negative size must match the content length.
+func (s *Storage) Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) (string, error) {
+	if err := validKey(objectName); err != nil {
+		return "", err
+	}
+
+	h := md5.New()
+	counter := &countingReader{r: io.TeeReader(r, h)}
+	if size >= 0 {
+		counter.r = io.LimitReader(counter.r, size+1)
+	}
+	if err := s.blobs.write(objectPath(objectName), counter); err != nil {
+		return "", fmt.Errorf("localstore put object: %w", err)
+	}
+	if size >= 0 && counter.n != size {
+		_ = s.blobs.remove(objectPath(objectName))
+		return "", fmt.Errorf("localstore put object: %w", errSizeMismatch)
+	}
+
+	etag := hex.EncodeToString(h.Sum(nil))
+	if err := s.writeMeta(objectName, objectMeta{ContentType: contentType, ETag: etag}); err != nil {
+		return "", err
+	}
+	return etag, nil
+}
+
+func (s *Storage) Delete(ctx context.Context, objectName string) error {
+	if err := validKey(objectName); err != nil {
+		return err
+	}
+	if err := s.blobs.remove(objectPath(objectName)); err != nil {
+		return fmt.Errorf("localstore remove object: %w", err)
+	}
+	if err := s.blobs.remove(metaPath(objectName)); err != nil {
+		return fmt.Errorf("localstore remove object: %w", err)
+	}
+	return nil
+}
+
+// Stat returns the metadata of a stored object. A missing object yields ErrObjectNotFound.
+func (s *Storage) Stat(ctx context.Context, objectName string) (*minio.ObjectInfo, error) {
+	if err := validKey(objectName); err != nil {
+		return nil, err
+	}
+
+	size, err := s.blobs.size(objectPath(objectName))
+	if errors.Is(err, errBlobNotFound) {
+		return nil, minio.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("localstore stat object: %w", err)
+	}
+
+	meta, err := s.readMeta(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &minio.ObjectInfo{
+		Key:         objectName,
+		Size:        size,
+		ContentType: meta.ContentType,
+		ETag:        meta.ETag,
+	}, nil
+}
+
+// Get opens a stored object for reading. A missing object yields ErrObjectNotFound.
+func (s *Storage) Get(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	if err := validKey(objectName); err != nil {
+		return nil, err
+	}
+
+	rc, err := s.blobs.open(objectPath(objectName))
+	if errors.Is(err, errBlobNotFound) {
+		return nil, minio.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("localstore get object: %w", err)
+	}
+	return rc, nil
+}
+
+func (s *Storage) PresignGet(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	return s.presign("GET", objectName, expiry)
+}
+
+func (s *Storage) PresignPut(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	return s.presign("PUT", objectName, expiry)
+}
+
+func (s *Storage) presign(method, objectName string, expiry time.Duration) (string, error) {
+	if err := validKey(objectName); err != nil {
+		return "", err
+	}
+	if expiry <= 0 {
+		expiry = time.Hour
+	}
+	return s.signer.URL(method, objectName, expiry), nil
+}
+
+func (s *Storage) NewMultipartUpload(ctx context.Context, objectName, contentType string) (string, error) {
+	if err := validKey(objectName); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("localstore new multipart upload: %w", err)
+	}
+	uploadID := hex.EncodeToString(b)
+
+	info, err := json.Marshal(uploadInfo{Key: objectName, ContentType: contentType})
+	if err != nil {
+		return "", fmt.Errorf("localstore new multipart upload: %w", err)
+	}
+	if err := s.blobs.write(uploadPath(uploadID, "info"), bytes.NewReader(info)); err != nil {
+		return "", fmt.Errorf("localstore new multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+func (s *Storage) PutPart(ctx context.Context, objectName, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	if _, err := s.upload(objectName, uploadID); err != nil {
+		return "", err
+	}
+	if number < 1 {
+		return "", fmt.Errorf("part number must be > 0")
+	}
+
+	h := md5.New()
+	counter := &countingReader{r: io.LimitReader(io.TeeReader(r, h), size+1)}
+	name := uploadPath(uploadID, strconv.Itoa(number))
+	if err := s.blobs.write(name, counter); err != nil {
+		return "", fmt.Errorf("localstore put object part: %w", err)
+	}
+	if counter.n != size {
+		_ = s.blobs.remove(name)
+		return "", fmt.Errorf("localstore put object part: %w", errSizeMismatch)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// CompleteMultipartUpload concatenates the parts in the given order into the object and
+// discards the upload.
+func (s *Storage) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []minio.Part) error {
+	info, err := s.upload(objectName, uploadID)
+	if err != nil {
+		return err
+	}
+
+	readers := make([]io.Reader, 0, len(parts))
+	for _, p := range parts {
+		rc, err := s.blobs.open(uploadPath(uploadID, strconv.Itoa(p.Number)))
+		if errors.Is(err, errBlobNotFound) {
+			err = fmt.Errorf("part %d is missing", p.Number)
+		}
+		if err != nil {
+			return fmt.Errorf("localstore complete multipart upload: %w", err)
+		}
+		defer rc.Close()
+		readers = append(readers, rc)
+	}
+
+	if _, err := s.Put(ctx, objectName, io.MultiReader(readers...), -1, info.ContentType); err != nil {
+		return fmt.Errorf("localstore complete multipart upload: %w", err)
+	}
+	if err := s.blobs.removePrefix(uploadPath(uploadID, "")); err != nil {
+		return fmt.Errorf("localstore complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	if _, err := s.upload(objectName, uploadID); err != nil {
+		return err
+	}
+	if err := s.blobs.removePrefix(uploadPath(uploadID, "")); err != nil {
+		return fmt.Errorf("localstore abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+// upload loads an open multipart upload and checks that it belongs to objectName.
+func (s *Storage) upload(objectName, uploadID string) (*uploadInfo, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return nil, ErrUploadNotFound
+	}
+
+	rc, err := s.blobs.open(uploadPath(uploadID, "info"))
+	if errors.Is(err, errBlobNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("localstore read upload: %w", err)
+	}
+	defer rc.Close()
+
+	var info uploadInfo
+	if err := json.NewDecoder(rc).Decode(&info); err != nil {
+		return nil, fmt.Errorf("localstore read upload: %w", err)
+	}
+	if info.Key != objectName {
+		return nil, ErrUploadNotFound
+	}
+	return &info, nil
+}
+
+func (s *Storage) writeMeta(objectName string, meta objectMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("localstore write metadata: %w", err)
+	}
+	if err := s.blobs.write(metaPath(objectName), bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("localstore write metadata: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) readMeta(objectName string) (objectMeta, error) {
+	var meta objectMeta
+
+	rc, err := s.blobs.open(metaPath(objectName))
+	if errors.Is(err, errBlobNotFound) {
+		return objectMeta{ContentType: "application/octet-stream"}, nil
+	}
+	if err != nil {
+		return meta, fmt.Errorf("localstore read metadata: %w", err)
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(rc).Decode(&meta); err != nil {
+		return meta, fmt.Errorf("localstore read metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// validKey rejects keys that would escape the storage directory or collide with the
+// internal layout.
+func validKey(objectName string) error {
+	if objectName == "" || strings.HasPrefix(objectName, "/") || strings.Contains(objectName, "\\") ||
+		strings.ContainsRune(objectName, 0) || path.Clean(objectName) != objectName {
+		return ErrInvalidKey
+	}
+	for _, seg := range strings.Split(objectName, "/") {
+		if seg == "." || seg == ".." || strings.HasPrefix(seg, ".tmp-") {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+func objectPath(objectName string) string { return "objects/" + objectName }
+func metaPath(objectName string) string   { return "meta/" + objectName }
+
+func uploadPath(uploadID, name string) string { return "multipart/" + uploadID + "/" + name }
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DevMailer delivers nothing: it logs the recipient and subject of every message and,
+// when dir is set, writes it to dir as an .eml file that any mail client can open. The
+// body is never logged, it carries verification codes.
+type DevMailer struct {
+	dir    string
+	from   mail.Address
+	logger zerolog.Logger
+}
+
+func NewDevMailer(dir, from string, logger zerolog.Logger) (*DevMailer, error) {
+	if from == "" {
+		from = "Chat-X <no-reply@localhost>"
+	}
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("mailer from address: %w", err)
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create mail dir: %w", err)
+		}
+	}
+	return &DevMailer{dir: dir, from: *addr, logger: logger}, nil
+}
+
+func (m *DevMailer) Send(ctx context.Context, msg Message) error {
+	evt := m.logger.Info().Str("to", msg.To).Str("subject", msg.Subject)
+
+	if m.dir != "" {
+		body, err := compose(m.from, msg)
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(m.dir, fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), randomToken()))
+		if err := os.WriteFile(name, body, 0o644); err != nil {
+			return fmt.Errorf("write mail: %w", err)
+		}
+		evt = evt.Str("file", name)
+	}
+
+	evt.Msg("email not delivered, dev mailer")
+	return nil
+}
@@ -0,0 +1,22 @@
+// Package mailer sends transactional email: over SMTP in production, to the log or to
+// .eml files in development, always through a queue that retries failed deliveries.
+package mailer
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+// Message is an email with a plain text body and an optional HTML alternative.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
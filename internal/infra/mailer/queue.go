@@ -0,0 +1,95 @@
+package mailer
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	queueSize   = 1000
+	workers     = 4
+	maxAttempts = 5
+
+	sendTimeout = 30 * time.Second
+	baseBackoff = 2 * time.Second
+	maxBackoff  = time.Minute
+)
+
+// Queue is a Mailer that hands messages to background workers, so callers never wait on
+// the mail server. Failed deliveries are retried with exponential backoff; messages still
+// queued when the context passed to Run is cancelled are dropped.
+type Queue struct {
+	mailer Mailer
+	jobs   chan Message
+	logger zerolog.Logger
+}
+
+func NewQueue(mailer Mailer, logger zerolog.Logger) *Queue {
+	return &Queue{
+		mailer: mailer,
+		jobs:   make(chan Message, queueSize),
+		logger: logger,
+	}
+}
+
+// Send enqueues msg. It fails with ErrQueueFull instead of blocking.
+func (q *Queue) Send(ctx context.Context, msg Message) error {
+	select {
+	case q.jobs <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run delivers queued messages until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-q.jobs:
+					q.deliver(ctx, msg)
+				}
+			}
+		}()
+	}
+	for i := 0; i < workers; i++ {
+		<-done
+	}
+
+	if n := len(q.jobs); n > 0 {
+		q.logger.Warn().Int("count", n).Msg("mail queue stopped with undelivered messages")
+	}
+}
+
+func (q *Queue) deliver(ctx context.Context, msg Message) {
+	backoff := baseBackoff
+	for attempt := 1; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := q.mailer.Send(sendCtx, msg)
+		cancel()
+		if err == nil {
+			return
+		}
+
+		if permanent(err) || attempt == maxAttempts {
+			q.logger.Error().Err(err).Str("to", msg.To).Str("subject", msg.Subject).Int("attempts", attempt).Msg("failed to send email")
+			return
+		}
+		q.logger.Warn().Err(err).Str("to", msg.To).Int("attempt", attempt).Dur("retry_in", backoff).Msg("failed to send email, retrying")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
@@ -3,6 +3,7 @@ package minio
 import (
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 
 type Storage struct {
 	client *minio.Client
+	core   minio.Core
 	bucket string
 }
 
@@ -32,7 +34,7 @@ func New(cfg config.MinioConfig) (*Storage, error) {
 		return nil, fmt.Errorf("minio init client: %w", err)
 	}
 
-	return &Storage{client: mc, bucket: cfg.Bucket}, nil
+	return &Storage{client: mc, core: minio.Core{Client: mc}, bucket: cfg.Bucket}, nil
 }
 
 func (s *Storage) EnsureBucket(ctx context.Context) error {
@@ -70,6 +72,20 @@ func (s *Storage) Upload(ctx context.Context, objectName string, file multipart.
 	return info.ETag, nil
 }
 
+func (s *Storage) Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) (string, error) {
+	if objectName == "" {
+		return "", fmt.Errorf("objectName is required")
+	}
+
+	info, err := s.client.PutObject(ctx, s.bucket, objectName, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("minio put object: %w", err)
+	}
+	return info.ETag, nil
+}
+
 func (s *Storage) Delete(ctx context.Context, objectName string) error {
 	if err := s.client.RemoveObject(ctx, s.bucket, objectName, minio.RemoveObjectOptions{}); err != nil {
 		return fmt.Errorf("minio remove object: %w", err)
@@ -77,6 +93,49 @@ func (s *Storage) Delete(ctx context.Context, objectName string) error {
 	return nil
 }
 
+// Stat returns the metadata of a stored object. A missing object yields ErrObjectNotFound.
+func (s *Storage) Stat(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	if objectName == "" {
+		return nil, fmt.Errorf("objectName is required")
+	}
+
+	info, err := s.client.StatObject(ctx, s.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("minio stat object: %w", err)
+	}
+
+	return &ObjectInfo{
+		Key:         info.Key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ETag:        info.ETag,
+	}, nil
+}
+
+// Get opens a stored object for reading. A missing object yields ErrObjectNotFound.
+func (s *Storage) Get(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	if objectName == "" {
+		return nil, fmt.Errorf("objectName is required")
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("minio get object: %w", err)
+	}
+	// GetObject is lazy, stat it so a missing object is reported here and not on first read
+	if _, err := obj.Stat(); err != nil {
+		_ = obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("minio get object: %w", err)
+	}
+	return obj, nil
+}
+
 func (s *Storage) PresignGet(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
 	if objectName == "" {
 		return "", fmt.Errorf("objectName is required")
@@ -106,3 +165,42 @@ func (s *Storage) PresignPut(ctx context.Context, objectName string, expiry time
 	}
 	return u.String(), nil
 }
+
+func (s *Storage) NewMultipartUpload(ctx context.Context, objectName, contentType string) (string, error) {
+	if objectName == "" {
+		return "", fmt.Errorf("objectName is required")
+	}
+
+	uploadID, err := s.core.NewMultipartUpload(ctx, s.bucket, objectName, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return "", fmt.Errorf("minio new multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+func (s *Storage) PutPart(ctx context.Context, objectName, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	part, err := s.core.PutObjectPart(ctx, s.bucket, objectName, uploadID, number, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", fmt.Errorf("minio put object part: %w", err)
+	}
+	return part.ETag, nil
+}
+
+func (s *Storage) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []Part) error {
+	completed := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		completed = append(completed, minio.CompletePart{PartNumber: p.Number, ETag: p.ETag})
+	}
+
+	if _, err := s.core.CompleteMultipartUpload(ctx, s.bucket, objectName, uploadID, completed, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("minio complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	if err := s.core.AbortMultipartUpload(ctx, s.bucket, objectName, uploadID); err != nil {
+		return fmt.Errorf("minio abort multipart upload: %w", err)
+	}
+	return nil
+}
@@ -2,16 +2,44 @@ package minio
 
 import (
 	"context"
+	"errors"
+	"io"
 	"mime/multipart"
 	"time"
 )
 
+var ErrObjectNotFound = errors.New("object not found")
+
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ETag        string
+}
+
+// Part is an uploaded part of a multipart upload.
+type Part struct {
+	Number int
+	ETag   string
+}
+
 type ObjectStorage interface {
 	EnsureBucket(ctx context.Context) error
 
 	Upload(ctx context.Context, objectName string, file multipart.File, size int64, contentType string) (etag string, err error)
+	// Put stores content produced by the server itself, such as generated thumbnails.
+	Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) (etag string, err error)
 	Delete(ctx context.Context, objectName string) error
+	Stat(ctx context.Context, objectName string) (*ObjectInfo, error)
+	// Get opens a stored object for reading; the caller closes it.
+	Get(ctx context.Context, objectName string) (io.ReadCloser, error)
 
 	PresignGet(ctx context.Context, objectName string, expiry time.Duration) (url string, err error)
 	PresignPut(ctx context.Context, objectName string, expiry time.Duration) (url string, err error)
+
+	// Multipart uploads; every part but the last must be at least 5 MiB.
+	NewMultipartUpload(ctx context.Context, objectName, contentType string) (uploadID string, err error)
+	PutPart(ctx context.Context, objectName, uploadID string, number int, r io.Reader, size int64) (etag string, err error)
+	CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []Part) error
+	AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	"github.com/lib/pq"
 )
 
 func (r *chatRepo) CreateConversation(ctx context.Context, conv *domain.Conversation) error {
@@ -14,29 +16,59 @@ func (r *chatRepo) CreateConversation(ctx context.Context, conv *domain.Conversa
 	
 	err := r.execer().QueryRowContext(ctx, query, conv.Type, conv.Title, conv.Username, conv.Description, conv.IsPublic, conv.CreatedBy).
 		Scan(&conv.ID, &conv.CreatedAt, &conv.UpdatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "conversations_username_key" {
+		return ErrUsernameTaken
+	}
 	return err
 }
 
-func (r *chatRepo) GetConversationByID(ctx context.Context, id uint64) (*domain.Conversation, error) {
-	query := `SELECT id, type, title, username, description, is_public, created_by, last_message_id, created_at, updated_at
-			  FROM conversations WHERE id = $1`
-	
-	var conv domain.Conversation
-	err := r.execer().QueryRowContext(ctx, query, id).Scan(
-		&conv.ID, &conv.Type, &conv.Title, &conv.Username, &conv.Description, &conv.IsPublic,
-		&conv.CreatedBy, &conv.LastMessageID, &conv.CreatedAt, &conv.UpdatedAt,
+// the member count is kept in shards by a trigger, see conversation_member_counts
+const conversationColumns = `c.id, c.type, c.title, c.username, c.description, c.is_public, c.created_by, c.last_message_id,
+	(SELECT COALESCE(SUM(mc.n), 0) FROM conversation_member_counts mc WHERE mc.conversation_id = c.id), c.created_at, c.updated_at`
+
+func scanConversation(row interface{ Scan(...any) error }) (*domain.Conversation, error) {
+	var c domain.Conversation
+	err := row.Scan(
+		&c.ID, &c.Type, &c.Title, &c.Username, &c.Description, &c.IsPublic,
+		&c.CreatedBy, &c.LastMessageID, &c.MemberCount, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+const messageColumns = `m.id, m.conversation_id, m.sender_id, m.type, m.text, m.created_at, m.edited_at,
+	m.reply_to_id, m.forward_from_id, m.forward_sender_id, m.deleted_at`
+
+func scanMessage(row interface{ Scan(...any) error }) (*domain.Message, error) {
+	var m domain.Message
+	err := row.Scan(
+		&m.ID, &m.ConversationID, &m.SenderID, &m.Type, &m.Text, &m.CreatedAt, &m.EditedAt,
+		&m.ReplyToID, &m.ForwardFromID, &m.ForwardSenderID, &m.DeletedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
-	return &conv, nil
+	return &m, nil
+}
+
+func (r *chatRepo) GetConversationByID(ctx context.Context, id uint64) (*domain.Conversation, error) {
+	query := `SELECT ` + conversationColumns + ` FROM conversations c WHERE c.id = $1`
+	return scanConversation(r.execer().QueryRowContext(ctx, query, id))
+}
+
+func (r *chatRepo) GetConversationByUsername(ctx context.Context, username string) (*domain.Conversation, error) {
+	query := `SELECT ` + conversationColumns + ` FROM conversations c WHERE c.username = $1`
+	return scanConversation(r.execer().QueryRowContext(ctx, query, username))
 }
 
 func (r *chatRepo) ListConversationsByUserID(ctx context.Context, userID uint64) ([]domain.Conversation, error) {
-	query := `SELECT c.id, c.type, c.title, c.username, c.description, c.is_public, c.created_by, c.last_message_id, c.created_at, c.updated_at
+	query := `SELECT ` + conversationColumns + `
 			  FROM conversations c
 			  JOIN conversation_participants cp ON c.id = cp.conversation_id
-			  WHERE cp.user_id = $1 AND cp.left_at IS NULL
+			  WHERE cp.user_id = $1 AND cp.left_at IS NULL AND cp.role NOT IN ('left', 'banned')
 			  ORDER BY c.updated_at DESC`
 	
 	rows, err := r.execer().QueryContext(ctx, query, userID)
@@ -47,12 +79,11 @@ func (r *chatRepo) ListConversationsByUserID(ctx context.Context, userID uint64)
 
 	var convs []domain.Conversation
 	for rows.Next() {
-		var c domain.Conversation
-		if err := rows.Scan(&c.ID, &c.Type, &c.Title, &c.Username, &c.Description, &c.IsPublic,
-			&c.CreatedBy, &c.LastMessageID, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		c, err := scanConversation(rows)
+		if err != nil {
 			return nil, err
 		}
-		convs = append(convs, c)
+		convs = append(convs, *c)
 	}
 	return convs, nil
 }
@@ -60,16 +91,57 @@ func (r *chatRepo) ListConversationsByUserID(ctx context.Context, userID uint64)
 func (r *chatRepo) AddParticipant(ctx context.Context, part *domain.Participant) error {
 	query := `INSERT INTO conversation_participants (conversation_id, user_id, role, joined_at)
 			  VALUES ($1, $2, $3, NOW())
-			  ON CONFLICT (conversation_id, user_id) DO UPDATE SET role = $3, left_at = NULL, joined_at = NOW()`
-	_, err := r.execer().ExecContext(ctx, query, part.ConversationID, part.UserID, part.Role)
+			  ON CONFLICT (conversation_id, user_id) DO UPDATE SET role = $3, left_at = NULL, joined_at = NOW(), banned_until = NULL
+			  WHERE conversation_participants.role <> 'banned' OR conversation_participants.banned_until <= NOW()
+			  RETURNING joined_at`
+	err := r.execer().QueryRowContext(ctx, query, part.ConversationID, part.UserID, part.Role).Scan(&part.JoinedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the conflicting row was left alone: the ban is still running
+		return ErrParticipantBanned
+	}
 	return err
 }
 
-func (r *chatRepo) GetParticipants(ctx context.Context, conversationID uint64) ([]domain.Participant, error) {
-	query := `SELECT conversation_id, user_id, role, joined_at, left_at, muted_until, is_pinned, last_read_message_id
-			  FROM conversation_participants WHERE conversation_id = $1 AND left_at IS NULL`
-	
-	rows, err := r.execer().QueryContext(ctx, query, conversationID)
+// memberRank orders the members of a page, it matches idx_participants_conv_page.
+const memberRank = `(CASE role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END)`
+
+func (r *chatRepo) GetMembersPage(ctx context.Context, conversationID, afterUserID uint64, limit int) ([]domain.Participant, error) {
+	query := `SELECT ` + participantColumns + `
+			  FROM conversation_participants
+			  WHERE conversation_id = $1 AND left_at IS NULL AND role NOT IN ('left', 'banned')
+			    AND ($2 = 0 OR (` + memberRank + `, joined_at, user_id) > (
+			      SELECT ` + memberRank + `, joined_at, user_id FROM conversation_participants
+			      WHERE conversation_id = $1 AND user_id = $2))
+			  ORDER BY ` + memberRank + `, joined_at, user_id
+			  LIMIT $3`
+
+	return r.queryParticipants(ctx, query, conversationID, afterUserID, limit)
+}
+
+func (r *chatRepo) GetParticipantsByRole(ctx context.Context, conversationID uint64, roles []domain.ParticipantRole) ([]domain.Participant, error) {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	query := `SELECT ` + participantColumns + `
+			  FROM conversation_participants
+			  WHERE conversation_id = $1 AND left_at IS NULL AND role = ANY($2)`
+
+	return r.queryParticipants(ctx, query, conversationID, pq.Array(names))
+}
+
+func (r *chatRepo) HasOtherMembers(ctx context.Context, conversationID, userID uint64) (bool, error) {
+	query := `SELECT EXISTS (
+			    SELECT 1 FROM conversation_participants
+			    WHERE conversation_id = $1 AND user_id <> $2 AND left_at IS NULL AND role NOT IN ('left', 'banned'))`
+
+	var exists bool
+	err := r.execer().QueryRowContext(ctx, query, conversationID, userID).Scan(&exists)
+	return exists, err
+}
+
+func (r *chatRepo) queryParticipants(ctx context.Context, query string, args ...any) ([]domain.Participant, error) {
+	rows, err := r.execer().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -77,26 +149,286 @@ func (r *chatRepo) GetParticipants(ctx context.Context, conversationID uint64) (
 
 	var participants []domain.Participant
 	for rows.Next() {
-		var p domain.Participant
-		if err := rows.Scan(&p.ConversationID, &p.UserID, &p.Role, &p.JoinedAt, &p.LeftAt, &p.MutedUntil, &p.IsPinned, &p.LastReadMessageID); err != nil {
+		p, err := scanParticipant(rows)
+		if err != nil {
 			return nil, err
 		}
-		participants = append(participants, p)
+		participants = append(participants, *p)
+	}
+	return participants, rows.Err()
+}
+
+func (r *chatRepo) GetParticipant(ctx context.Context, conversationID, userID uint64) (*domain.Participant, error) {
+	query := `SELECT ` + participantColumns + `
+			  FROM conversation_participants WHERE conversation_id = $1 AND user_id = $2`
+
+	return scanParticipant(r.execer().QueryRowContext(ctx, query, conversationID, userID))
+}
+
+const participantColumns = `conversation_id, user_id, role, joined_at, left_at, muted_until, is_pinned, last_read_message_id,
+			  banned_until, restrictions, restricted_until`
+
+func scanParticipant(row interface{ Scan(dest ...any) error }) (*domain.Participant, error) {
+	var (
+		p            domain.Participant
+		restrictions pq.StringArray
+	)
+	err := row.Scan(
+		&p.ConversationID, &p.UserID, &p.Role, &p.JoinedAt, &p.LeftAt, &p.MutedUntil, &p.IsPinned, &p.LastReadMessageID,
+		&p.BannedUntil, &restrictions, &p.RestrictedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range restrictions {
+		p.Restrictions = append(p.Restrictions, domain.Restriction(r))
 	}
-	return participants, nil
+	return &p, nil
 }
 
 func (r *chatRepo) RemoveParticipant(ctx context.Context, conversationID, userID uint64) error {
-	query := `UPDATE conversation_participants SET left_at = NOW() WHERE conversation_id = $1 AND user_id = $2`
+	query := `UPDATE conversation_participants SET left_at = NOW(), role = 'left' WHERE conversation_id = $1 AND user_id = $2`
+	_, err := r.execer().ExecContext(ctx, query, conversationID, userID)
+	return err
+}
+
+func (r *chatRepo) UpdateParticipantRole(ctx context.Context, conversationID, userID uint64, from, to domain.ParticipantRole) (bool, error) {
+	query := `UPDATE conversation_participants SET role = $4
+			  WHERE conversation_id = $1 AND user_id = $2 AND role = $3 AND left_at IS NULL`
+	res, err := r.execer().ExecContext(ctx, query, conversationID, userID, from, to)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *chatRepo) BanParticipant(ctx context.Context, conversationID, userID uint64, until *time.Time) error {
+	query := `INSERT INTO conversation_participants (conversation_id, user_id, role, joined_at, left_at, banned_until)
+			  VALUES ($1, $2, 'banned', NOW(), NOW(), $3)
+			  ON CONFLICT (conversation_id, user_id) DO UPDATE
+			  SET role = 'banned', banned_until = $3, left_at = COALESCE(conversation_participants.left_at, NOW())`
+	_, err := r.execer().ExecContext(ctx, query, conversationID, userID, until)
+	return err
+}
+
+func (r *chatRepo) UnbanParticipant(ctx context.Context, conversationID, userID uint64) (bool, error) {
+	query := `UPDATE conversation_participants SET role = 'left', banned_until = NULL
+			  WHERE conversation_id = $1 AND user_id = $2 AND role = 'banned'`
+	res, err := r.execer().ExecContext(ctx, query, conversationID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *chatRepo) RestrictParticipant(ctx context.Context, conversationID, userID uint64, from domain.ParticipantRole, restrictions []domain.Restriction, until *time.Time) (bool, error) {
+	arr := make([]string, 0, len(restrictions))
+	for _, r := range restrictions {
+		arr = append(arr, string(r))
+	}
+
+	query := `UPDATE conversation_participants SET role = 'restricted', restrictions = $4, restricted_until = $5
+			  WHERE conversation_id = $1 AND user_id = $2 AND role = $3 AND left_at IS NULL`
+	res, err := r.execer().ExecContext(ctx, query, conversationID, userID, from, pq.Array(arr), until)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *chatRepo) UnrestrictParticipant(ctx context.Context, conversationID, userID uint64) error {
+	query := `UPDATE conversation_participants
+			  SET role = CASE WHEN role = 'restricted' THEN 'member' ELSE role END, restrictions = '{}', restricted_until = NULL
+			  WHERE conversation_id = $1 AND user_id = $2`
 	_, err := r.execer().ExecContext(ctx, query, conversationID, userID)
 	return err
 }
 
+func (r *chatRepo) SetMutedUntil(ctx context.Context, conversationID, userID uint64, until *time.Time) error {
+	query := `UPDATE conversation_participants SET muted_until = $3 WHERE conversation_id = $1 AND user_id = $2`
+	_, err := r.execer().ExecContext(ctx, query, conversationID, userID, until)
+	return err
+}
+
+func (r *chatRepo) AddModerationAction(ctx context.Context, action *domain.ModerationAction) error {
+	arr := make([]string, 0, len(action.Restrictions))
+	for _, r := range action.Restrictions {
+		arr = append(arr, string(r))
+	}
+
+	query := `INSERT INTO moderation_actions (conversation_id, actor_id, target_id, action, restrictions, until, reason)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`
+	return r.execer().QueryRowContext(ctx, query, action.ConversationID, action.ActorID, action.TargetID, action.Action,
+		pq.Array(arr), action.Until, action.Reason).Scan(&action.ID, &action.CreatedAt)
+}
+
+func (r *chatRepo) GetModerationActions(ctx context.Context, conversationID, beforeID uint64, limit int) ([]domain.ModerationAction, error) {
+	query := `SELECT id, conversation_id, actor_id, target_id, action, restrictions, until, reason, created_at
+			  FROM moderation_actions
+			  WHERE conversation_id = $1 AND ($2 = 0 OR id < $2)
+			  ORDER BY id DESC LIMIT $3`
+
+	rows, err := r.execer().QueryContext(ctx, query, conversationID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []domain.ModerationAction
+	for rows.Next() {
+		var (
+			a            domain.ModerationAction
+			restrictions pq.StringArray
+		)
+		if err := rows.Scan(&a.ID, &a.ConversationID, &a.ActorID, &a.TargetID, &a.Action, &restrictions, &a.Until, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		for _, r := range restrictions {
+			a.Restrictions = append(a.Restrictions, domain.Restriction(r))
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+const inviteLinkColumns = `id, conversation_id, code, created_by, created_at, expires_at, max_uses, uses_count, is_active, requires_approval`
+
+func scanInviteLink(row interface{ Scan(...any) error }) (*domain.InviteLink, error) {
+	var l domain.InviteLink
+	err := row.Scan(&l.ID, &l.ConversationID, &l.Code, &l.CreatedBy, &l.CreatedAt, &l.ExpiresAt, &l.MaxUses, &l.UsesCount, &l.IsActive, &l.RequiresApproval)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *chatRepo) CreateInviteLink(ctx context.Context, link *domain.InviteLink) error {
+	query := `INSERT INTO invite_links (conversation_id, code, created_by, expires_at, max_uses, requires_approval)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, uses_count, is_active`
+	return r.execer().QueryRowContext(ctx, query, link.ConversationID, link.Code, link.CreatedBy, link.ExpiresAt, link.MaxUses, link.RequiresApproval).
+		Scan(&link.ID, &link.CreatedAt, &link.UsesCount, &link.IsActive)
+}
+
+func (r *chatRepo) GetInviteLinks(ctx context.Context, conversationID uint64) ([]domain.InviteLink, error) {
+	query := `SELECT ` + inviteLinkColumns + ` FROM invite_links WHERE conversation_id = $1 ORDER BY id DESC`
+	rows, err := r.execer().QueryContext(ctx, query, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []domain.InviteLink
+	for rows.Next() {
+		l, err := scanInviteLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *l)
+	}
+	return links, rows.Err()
+}
+
+func (r *chatRepo) GetInviteLinkByCode(ctx context.Context, code string) (*domain.InviteLink, error) {
+	query := `SELECT ` + inviteLinkColumns + ` FROM invite_links WHERE code = $1`
+	return scanInviteLink(r.execer().QueryRowContext(ctx, query, code))
+}
+
+func (r *chatRepo) RevokeInviteLink(ctx context.Context, conversationID, linkID uint64) (bool, error) {
+	query := `UPDATE invite_links SET is_active = FALSE WHERE id = $1 AND conversation_id = $2 AND is_active`
+	res, err := r.execer().ExecContext(ctx, query, linkID, conversationID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *chatRepo) UseInviteLink(ctx context.Context, linkID uint64) (bool, error) {
+	query := `UPDATE invite_links SET uses_count = uses_count + 1
+			  WHERE id = $1 AND is_active
+			    AND (expires_at IS NULL OR expires_at > NOW())
+			    AND (max_uses IS NULL OR uses_count < max_uses)`
+	res, err := r.execer().ExecContext(ctx, query, linkID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *chatRepo) AddJoinRequest(ctx context.Context, req *domain.JoinRequest) (bool, error) {
+	query := `INSERT INTO join_requests (conversation_id, user_id, message) VALUES ($1, $2, $3)
+			  ON CONFLICT (conversation_id, user_id) DO NOTHING RETURNING requested_at`
+	err := r.execer().QueryRowContext(ctx, query, req.ConversationID, req.UserID, req.Message).Scan(&req.RequestedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *chatRepo) GetJoinRequests(ctx context.Context, conversationID uint64) ([]domain.JoinRequest, error) {
+	query := `SELECT conversation_id, user_id, requested_at, message FROM join_requests
+			  WHERE conversation_id = $1 ORDER BY requested_at, user_id`
+	rows, err := r.execer().QueryContext(ctx, query, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reqs []domain.JoinRequest
+	for rows.Next() {
+		var jr domain.JoinRequest
+		if err := rows.Scan(&jr.ConversationID, &jr.UserID, &jr.RequestedAt, &jr.Message); err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, jr)
+	}
+	return reqs, rows.Err()
+}
+
+func (r *chatRepo) DeleteJoinRequest(ctx context.Context, conversationID, userID uint64) (bool, error) {
+	query := `DELETE FROM join_requests WHERE conversation_id = $1 AND user_id = $2`
+	res, err := r.execer().ExecContext(ctx, query, conversationID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *chatRepo) ExistingUserIDs(ctx context.Context, ids []uint64) ([]uint64, error) {
+	arr := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		arr = append(arr, int64(id))
+	}
+
+	query := `SELECT id FROM users WHERE id = ANY($1)`
+
+	rows, err := r.execer().QueryContext(ctx, query, pq.Array(arr))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var existing []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existing = append(existing, id)
+	}
+	return existing, rows.Err()
+}
+
 func (r *chatRepo) SendMessage(ctx context.Context, msg *domain.Message) error {
-	query := `INSERT INTO messages (conversation_id, sender_id, type, text, created_at)
-			  VALUES ($1, $2, $3, $4, NOW()) RETURNING id, created_at`
+	query := `INSERT INTO messages (conversation_id, sender_id, type, text, reply_to_id, forward_from_id, forward_sender_id, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, NOW()) RETURNING id, created_at`
 	
-	err := r.execer().QueryRowContext(ctx, query, msg.ConversationID, msg.SenderID, msg.Type, msg.Text).
+	err := r.execer().QueryRowContext(ctx, query, msg.ConversationID, msg.SenderID, msg.Type, msg.Text, msg.ReplyToID, msg.ForwardFromID, msg.ForwardSenderID).
 		Scan(&msg.ID, &msg.CreatedAt)
 	if err != nil {
 		return err
@@ -108,12 +440,43 @@ func (r *chatRepo) SendMessage(ctx context.Context, msg *domain.Message) error {
 	return err
 }
 
-func (r *chatRepo) GetMessages(ctx context.Context, conversationID uint64, limit, offset int) ([]domain.Message, error) {
-	query := `SELECT id, conversation_id, sender_id, type, text, created_at, edited_at, reply_to_id, forward_from_id, deleted_at
-			  FROM messages WHERE conversation_id = $1 AND deleted_at IS NULL
-			  ORDER BY created_at DESC LIMIT $2 OFFSET $3`
-	
-	rows, err := r.execer().QueryContext(ctx, query, conversationID, limit, offset)
+// GetMessagesBefore returns up to limit messages older than beforeID and not older than since, newest first,
+// leaving out messages the viewer deleted for themselves. A zero beforeID starts from the latest message. Paging walks idx_messages_conv_created
+// with (created_at, id) as the key, so it neither slows down with depth nor skips rows
+// when new messages arrive.
+func (r *chatRepo) GetMessagesBefore(ctx context.Context, conversationID, viewerID, beforeID uint64, since time.Time, limit int) ([]domain.Message, error) {
+	if beforeID == 0 {
+		query := `SELECT ` + messageColumns + `
+				  FROM messages m
+				  WHERE m.conversation_id = $1 AND m.deleted_at IS NULL AND m.created_at >= $3
+				  AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.user_id = $2 AND h.message_id = m.id)
+				  ORDER BY m.created_at DESC, m.id DESC LIMIT $4`
+		return r.queryMessages(ctx, query, conversationID, viewerID, since, limit)
+	}
+
+	query := `SELECT ` + messageColumns + `
+			  FROM messages m
+			  WHERE m.conversation_id = $1 AND m.deleted_at IS NULL AND m.created_at >= $4
+			  AND (m.created_at, m.id) < (SELECT created_at, id FROM messages WHERE id = $3 AND conversation_id = $1)
+			  AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.user_id = $2 AND h.message_id = m.id)
+			  ORDER BY m.created_at DESC, m.id DESC LIMIT $5`
+	return r.queryMessages(ctx, query, conversationID, viewerID, beforeID, since, limit)
+}
+
+// GetMessagesAfter returns up to limit messages newer than afterID and not older than since, oldest first,
+// leaving out messages the viewer deleted for themselves.
+func (r *chatRepo) GetMessagesAfter(ctx context.Context, conversationID, viewerID, afterID uint64, since time.Time, limit int) ([]domain.Message, error) {
+	query := `SELECT ` + messageColumns + `
+			  FROM messages m
+			  WHERE m.conversation_id = $1 AND m.deleted_at IS NULL AND m.created_at >= $4
+			  AND (m.created_at, m.id) > (SELECT created_at, id FROM messages WHERE id = $3 AND conversation_id = $1)
+			  AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.user_id = $2 AND h.message_id = m.id)
+			  ORDER BY m.created_at ASC, m.id ASC LIMIT $5`
+	return r.queryMessages(ctx, query, conversationID, viewerID, afterID, since, limit)
+}
+
+func (r *chatRepo) queryMessages(ctx context.Context, query string, args ...any) ([]domain.Message, error) {
+	rows, err := r.execer().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -121,33 +484,48 @@ func (r *chatRepo) GetMessages(ctx context.Context, conversationID uint64, limit
 
 	var msgs []domain.Message
 	for rows.Next() {
-		var m domain.Message
-		if err := rows.Scan(&m.ID, &m.ConversationID, &m.SenderID, &m.Type, &m.Text, &m.CreatedAt, &m.EditedAt, &m.ReplyToID, &m.ForwardFromID, &m.DeletedAt); err != nil {
+		m, err := scanMessage(rows)
+		if err != nil {
 			return nil, err
 		}
-		msgs = append(msgs, m)
+		msgs = append(msgs, *m)
 	}
-	return msgs, nil
+	return msgs, rows.Err()
 }
 
 func (r *chatRepo) GetMessageByID(ctx context.Context, id uint64) (*domain.Message, error) {
-	query := `SELECT id, conversation_id, sender_id, type, text, created_at, edited_at, reply_to_id, forward_from_id, deleted_at
-			  FROM messages WHERE id = $1`
-	
-	var m domain.Message
-	err := r.execer().QueryRowContext(ctx, query, id).Scan(
-		&m.ID, &m.ConversationID, &m.SenderID, &m.Type, &m.Text, &m.CreatedAt, &m.EditedAt, &m.ReplyToID, &m.ForwardFromID, &m.DeletedAt,
-	)
-	if err != nil {
-		return nil, err
+	return r.getMessage(ctx, id, "")
+}
+
+// GetMessageForUpdate loads a message and locks it until the transaction ends.
+func (r *chatRepo) GetMessageForUpdate(ctx context.Context, id uint64) (*domain.Message, error) {
+	return r.getMessage(ctx, id, " FOR UPDATE")
+}
+
+func (r *chatRepo) getMessage(ctx context.Context, id uint64, lock string) (*domain.Message, error) {
+	query := `SELECT ` + messageColumns + ` FROM messages m WHERE m.id = $1` + lock
+	return scanMessage(r.execer().QueryRowContext(ctx, query, id))
+}
+
+// GetMessagesByIDs loads several messages at once, deleted ones included, in ID order.
+func (r *chatRepo) GetMessagesByIDs(ctx context.Context, ids []uint64) ([]domain.Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
-	return &m, nil
+
+	arr := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		arr = append(arr, int64(id))
+	}
+
+	query := `SELECT ` + messageColumns + ` FROM messages m WHERE m.id = ANY($1) ORDER BY m.id`
+	return r.queryMessages(ctx, query, pq.Array(arr))
 }
 
 func (r *chatRepo) UpdateMessage(ctx context.Context, msg *domain.Message) error {
-	query := `UPDATE messages SET text = $1, edited_at = NOW() WHERE id = $2 AND sender_id = $3`
-	_, err := r.execer().ExecContext(ctx, query, msg.Text, msg.ID, msg.SenderID)
-	return err
+	query := `UPDATE messages SET text = $1, edited_at = NOW() WHERE id = $2 AND sender_id = $3 AND deleted_at IS NULL
+			  RETURNING edited_at`
+	return r.execer().QueryRowContext(ctx, query, msg.Text, msg.ID, msg.SenderID).Scan(&msg.EditedAt)
 }
 
 func (r *chatRepo) DeleteMessage(ctx context.Context, id uint64) error {
@@ -156,6 +534,306 @@ func (r *chatRepo) DeleteMessage(ctx context.Context, id uint64) error {
 	return err
 }
 
+func (r *chatRepo) AddAttachment(ctx context.Context, att *domain.Attachment) error {
+	query := `INSERT INTO message_attachments (message_id, storage_key, mime_type, size_bytes, width, height)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	return r.execer().QueryRowContext(ctx, query, att.MessageID, att.StorageKey, att.MimeType, att.SizeBytes, att.Width, att.Height).
+		Scan(&att.ID, &att.CreatedAt)
+}
+
+// DeleteAttachments removes the attachments of a message and returns their storage keys,
+// so the references they held can be released.
+func (r *chatRepo) DeleteAttachments(ctx context.Context, messageID uint64) ([]string, error) {
+	query := `DELETE FROM message_attachments WHERE message_id = $1 RETURNING storage_key`
+
+	rows, err := r.execer().QueryContext(ctx, query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// GetAttachments loads the attachments of several messages, keyed by message ID.
+func (r *chatRepo) GetAttachments(ctx context.Context, messageIDs []uint64) (map[uint64][]domain.Attachment, error) {
+	atts := make(map[uint64][]domain.Attachment)
+	if len(messageIDs) == 0 {
+		return atts, nil
+	}
+
+	arr := make([]int64, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		arr = append(arr, int64(id))
+	}
+
+	query := `SELECT id, message_id, storage_key, mime_type, size_bytes, width, height, created_at
+			  FROM message_attachments WHERE message_id = ANY($1) ORDER BY id`
+
+	rows, err := r.execer().QueryContext(ctx, query, pq.Array(arr))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a domain.Attachment
+		if err := rows.Scan(&a.ID, &a.MessageID, &a.StorageKey, &a.MimeType, &a.SizeBytes, &a.Width, &a.Height, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		atts[a.MessageID] = append(atts[a.MessageID], a)
+	}
+	return atts, rows.Err()
+}
+
+func (r *chatRepo) AddMessageEdit(ctx context.Context, edit *domain.MessageEdit) error {
+	query := `INSERT INTO message_edits (message_id, editor_id, old_text, edited_at)
+			  VALUES ($1, $2, $3, NOW()) RETURNING id, edited_at`
+	return r.execer().QueryRowContext(ctx, query, edit.MessageID, edit.EditorID, edit.OldText).Scan(&edit.ID, &edit.EditedAt)
+}
+
+// GetMessageEdits returns the revisions of a message, oldest first.
+func (r *chatRepo) GetMessageEdits(ctx context.Context, messageID uint64) ([]domain.MessageEdit, error) {
+	query := `SELECT id, message_id, editor_id, old_text, edited_at
+			  FROM message_edits WHERE message_id = $1 ORDER BY edited_at ASC, id ASC`
+
+	rows, err := r.execer().QueryContext(ctx, query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []domain.MessageEdit
+	for rows.Next() {
+		var e domain.MessageEdit
+		if err := rows.Scan(&e.ID, &e.MessageID, &e.EditorID, &e.OldText, &e.EditedAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, e)
+	}
+	return edits, rows.Err()
+}
+
+func (r *chatRepo) HideMessage(ctx context.Context, messageID, userID uint64) error {
+	query := `INSERT INTO hidden_messages (message_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.execer().ExecContext(ctx, query, messageID, userID)
+	return err
+}
+
+func (r *chatRepo) IsMessageHidden(ctx context.Context, messageID, userID uint64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM hidden_messages WHERE message_id = $1 AND user_id = $2)`
+
+	var hidden bool
+	err := r.execer().QueryRowContext(ctx, query, messageID, userID).Scan(&hidden)
+	return hidden, err
+}
+
+// GetHiddenMessageIDs returns the messages among messageIDs the user deleted "for me".
+func (r *chatRepo) GetHiddenMessageIDs(ctx context.Context, userID uint64, messageIDs []uint64) (map[uint64]bool, error) {
+	hidden := make(map[uint64]bool)
+	if len(messageIDs) == 0 {
+		return hidden, nil
+	}
+
+	arr := make([]int64, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		arr = append(arr, int64(id))
+	}
+
+	query := `SELECT message_id FROM hidden_messages WHERE user_id = $1 AND message_id = ANY($2)`
+
+	rows, err := r.execer().QueryContext(ctx, query, userID, pq.Array(arr))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		hidden[id] = true
+	}
+	return hidden, rows.Err()
+}
+
+// AddReaction reports false when the user has already reacted to the message with the emoji.
+func (r *chatRepo) AddReaction(ctx context.Context, reaction *domain.Reaction) (bool, error) {
+	query := `INSERT INTO message_reactions (message_id, user_id, emoji) VALUES ($1, $2, $3)
+			  ON CONFLICT DO NOTHING RETURNING created_at`
+
+	err := r.execer().QueryRowContext(ctx, query, reaction.MessageID, reaction.UserID, reaction.Emoji).Scan(&reaction.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveReaction reports false when there was no such reaction.
+func (r *chatRepo) RemoveReaction(ctx context.Context, messageID, userID uint64, emoji string) (bool, error) {
+	query := `DELETE FROM message_reactions WHERE message_id = $1 AND user_id = $2 AND emoji = $3`
+
+	res, err := r.execer().ExecContext(ctx, query, messageID, userID, emoji)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *chatRepo) GetReactions(ctx context.Context, messageID uint64) ([]domain.Reaction, error) {
+	query := `SELECT message_id, user_id, emoji, created_at
+			  FROM message_reactions WHERE message_id = $1 ORDER BY created_at ASC`
+
+	rows, err := r.execer().QueryContext(ctx, query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reactions []domain.Reaction
+	for rows.Next() {
+		var rc domain.Reaction
+		if err := rows.Scan(&rc.MessageID, &rc.UserID, &rc.Emoji, &rc.CreatedAt); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, rc)
+	}
+	return reactions, rows.Err()
+}
+
+// GetReactionCounts aggregates the reactions of a page of messages in one query, keyed by
+// message ID. Emojis are ordered by their first use.
+func (r *chatRepo) GetReactionCounts(ctx context.Context, messageIDs []uint64, viewerID uint64) (map[uint64][]domain.ReactionCount, error) {
+	counts := make(map[uint64][]domain.ReactionCount)
+	if len(messageIDs) == 0 {
+		return counts, nil
+	}
+
+	arr := make([]int64, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		arr = append(arr, int64(id))
+	}
+
+	query := `SELECT message_id, emoji, COUNT(*), BOOL_OR(user_id = $2)
+			  FROM message_reactions WHERE message_id = ANY($1)
+			  GROUP BY message_id, emoji
+			  ORDER BY message_id, MIN(created_at)`
+
+	rows, err := r.execer().QueryContext(ctx, query, pq.Array(arr), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			msgID uint64
+			rc    domain.ReactionCount
+		)
+		if err := rows.Scan(&msgID, &rc.Emoji, &rc.Count, &rc.Reacted); err != nil {
+			return nil, err
+		}
+		counts[msgID] = append(counts[msgID], rc)
+	}
+	return counts, rows.Err()
+}
+
+// MarkRead moves the participant's read watermark forward to receipt.MessageID.
+// It reports false when the watermark is already at or past the message.
+func (r *chatRepo) MarkRead(ctx context.Context, receipt *domain.ReadReceipt) (bool, error) {
+	query := `UPDATE conversation_participants SET last_read_message_id = $3, last_read_at = NOW()
+			  WHERE conversation_id = $1 AND user_id = $2
+			  AND (last_read_message_id IS NULL OR last_read_message_id < $3)
+			  RETURNING last_read_at`
+
+	err := r.execer().QueryRowContext(ctx, query, receipt.ConversationID, receipt.UserID, receipt.MessageID).Scan(&receipt.ReadAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetUnreadCounts returns the number of unread messages per conversation of the user,
+// counting at most limit per conversation so a long absence never scans a whole history.
+// Only messages after each read watermark are scanned, through idx_messages_conv_id;
+// conversations without unread messages are left out.
+func (r *chatRepo) GetUnreadCounts(ctx context.Context, userID uint64, limit int) (map[uint64]int, error) {
+	query := `SELECT cp.conversation_id, unread.n
+			  FROM conversation_participants cp
+			  CROSS JOIN LATERAL (
+			  	SELECT COUNT(*) AS n FROM (
+			  		SELECT 1 FROM messages m
+			  		WHERE m.conversation_id = cp.conversation_id
+			  		AND m.id > COALESCE(cp.last_read_message_id, 0)
+			  		AND m.deleted_at IS NULL
+			  		AND m.created_at >= cp.joined_at
+			  		AND m.sender_id IS DISTINCT FROM cp.user_id
+			  		AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.user_id = $1 AND h.message_id = m.id)
+			  		LIMIT $2
+			  	) capped
+			  ) unread
+			  WHERE cp.user_id = $1 AND cp.left_at IS NULL AND cp.role NOT IN ('left', 'banned') AND unread.n > 0`
+
+	rows, err := r.execer().QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uint64]int)
+	for rows.Next() {
+		var (
+			convID uint64
+			count  int
+		)
+		if err := rows.Scan(&convID, &count); err != nil {
+			return nil, err
+		}
+		counts[convID] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetReadReceipts returns the participants whose read watermark has reached the message.
+func (r *chatRepo) GetReadReceipts(ctx context.Context, conversationID, messageID uint64) ([]domain.ReadReceipt, error) {
+	query := `SELECT user_id, last_read_message_id, last_read_at
+			  FROM conversation_participants
+			  WHERE conversation_id = $1 AND last_read_message_id >= $2 AND left_at IS NULL
+			  ORDER BY last_read_at ASC`
+
+	rows, err := r.execer().QueryContext(ctx, query, conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []domain.ReadReceipt
+	for rows.Next() {
+		rr := domain.ReadReceipt{ConversationID: conversationID}
+		if err := rows.Scan(&rr.UserID, &rr.MessageID, &rr.ReadAt); err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, rr)
+	}
+	return receipts, rows.Err()
+}
+
 func (r *chatRepo) GetDMConversation(ctx context.Context, user1ID, user2ID uint64) (*domain.Conversation, error) {
 	// Ensure user1ID < user2ID as per DM pairs check
 	u1, u2 := user1ID, user2ID
@@ -163,23 +841,19 @@ func (r *chatRepo) GetDMConversation(ctx context.Context, user1ID, user2ID uint6
 		u1, u2 = u2, u1
 	}
 
-	query := `SELECT c.id, c.type, c.title, c.username, c.description, c.is_public, c.created_by, c.last_message_id, c.created_at, c.updated_at
+	query := `SELECT ` + conversationColumns + `
 			  FROM conversations c
 			  JOIN dm_pairs dm ON c.id = dm.conversation_id
 			  WHERE dm.user1_id = $1 AND dm.user2_id = $2`
-	
-	var c domain.Conversation
-	err := r.execer().QueryRowContext(ctx, query, u1, u2).Scan(
-		&c.ID, &c.Type, &c.Title, &c.Username, &c.Description, &c.IsPublic,
-		&c.CreatedBy, &c.LastMessageID, &c.CreatedAt, &c.UpdatedAt,
-	)
+
+	c, err := scanConversation(r.execer().QueryRowContext(ctx, query, u1, u2))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &c, nil
+	return c, nil
 }
 
 func (r *chatRepo) CreateDMConversation(ctx context.Context, user1ID, user2ID uint64, convID uint64) error {
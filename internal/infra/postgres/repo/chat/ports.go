@@ -3,30 +3,120 @@ package chat
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 )
 
+// ErrUsernameTaken is returned when a conversation is created with a username in use.
+var ErrUsernameTaken = errors.New("username is taken")
+
+// ErrParticipantBanned is returned when a user whose ban is still running is added back.
+var ErrParticipantBanned = errors.New("participant is banned")
+
 type ChatStore interface {
 	WithTx(tx *sql.Tx) *chatRepo
 
 	// Conversations
 	CreateConversation(ctx context.Context, conv *domain.Conversation) error
 	GetConversationByID(ctx context.Context, id uint64) (*domain.Conversation, error)
+	GetConversationByUsername(ctx context.Context, username string) (*domain.Conversation, error)
 	ListConversationsByUserID(ctx context.Context, userID uint64) ([]domain.Conversation, error)
 	
 	// Participants
+	// AddParticipant adds the user or brings them back. It fails with ErrParticipantBanned
+	// while the user is banned, the check is part of the write so a concurrent ban wins.
 	AddParticipant(ctx context.Context, part *domain.Participant) error
-	GetParticipants(ctx context.Context, conversationID uint64) ([]domain.Participant, error)
+	// GetMembersPage returns up to limit active members, owner and admins first, then by
+	// join time. A non zero afterUserID continues after that member.
+	GetMembersPage(ctx context.Context, conversationID, afterUserID uint64, limit int) ([]domain.Participant, error)
+	// GetParticipantsByRole returns the participants who have one of the roles and have
+	// not left.
+	GetParticipantsByRole(ctx context.Context, conversationID uint64, roles []domain.ParticipantRole) ([]domain.Participant, error)
+	// HasOtherMembers reports whether anyone but the user is active in the conversation.
+	HasOtherMembers(ctx context.Context, conversationID, userID uint64) (bool, error)
+	GetParticipant(ctx context.Context, conversationID, userID uint64) (*domain.Participant, error)
 	RemoveParticipant(ctx context.Context, conversationID, userID uint64) error
+	// UpdateParticipantRole changes the role of an active participant from one role to
+	// another and reports false when the participant no longer has the expected role.
+	UpdateParticipantRole(ctx context.Context, conversationID, userID uint64, from, to domain.ParticipantRole) (bool, error)
+
+	// Moderation
+	// BanParticipant bans the user until the given time, or for good when until is nil.
+	// Users who never joined can be banned too.
+	BanParticipant(ctx context.Context, conversationID, userID uint64, until *time.Time) error
+	// UnbanParticipant lifts a ban, leaving the user free to rejoin. It reports false
+	// when the user was not banned.
+	UnbanParticipant(ctx context.Context, conversationID, userID uint64) (bool, error)
+	// RestrictParticipant restricts an active participant who still has the role from.
+	RestrictParticipant(ctx context.Context, conversationID, userID uint64, from domain.ParticipantRole, restrictions []domain.Restriction, until *time.Time) (bool, error)
+	UnrestrictParticipant(ctx context.Context, conversationID, userID uint64) error
+	// SetMutedUntil mutes the participant until the given time, or unmutes them when nil.
+	SetMutedUntil(ctx context.Context, conversationID, userID uint64, until *time.Time) error
+	AddModerationAction(ctx context.Context, action *domain.ModerationAction) error
+	// GetModerationActions returns up to limit log entries older than beforeID, newest
+	// first. A zero beforeID starts from the latest.
+	GetModerationActions(ctx context.Context, conversationID, beforeID uint64, limit int) ([]domain.ModerationAction, error)
+
+	// Invite links
+	CreateInviteLink(ctx context.Context, link *domain.InviteLink) error
+	// GetInviteLinks returns the links of a conversation, newest first.
+	GetInviteLinks(ctx context.Context, conversationID uint64) ([]domain.InviteLink, error)
+	GetInviteLinkByCode(ctx context.Context, code string) (*domain.InviteLink, error)
+	// RevokeInviteLink deactivates a link. It reports false when the conversation has no
+	// such active link.
+	RevokeInviteLink(ctx context.Context, conversationID, linkID uint64) (bool, error)
+	// UseInviteLink counts a use of the link unless it is revoked, expired or used up,
+	// in which case it reports false.
+	UseInviteLink(ctx context.Context, linkID uint64) (bool, error)
+
+	// AddJoinRequest queues the user for approval. It reports false when they are already
+	// waiting.
+	AddJoinRequest(ctx context.Context, req *domain.JoinRequest) (bool, error)
+	// GetJoinRequests returns the pending requests of a conversation, oldest first.
+	GetJoinRequests(ctx context.Context, conversationID uint64) ([]domain.JoinRequest, error)
+	// DeleteJoinRequest drops a request. It reports false when there was none.
+	DeleteJoinRequest(ctx context.Context, conversationID, userID uint64) (bool, error)
+
+	// ExistingUserIDs returns the ids among ids that belong to users.
+	ExistingUserIDs(ctx context.Context, ids []uint64) ([]uint64, error)
 
 	// Messages
 	SendMessage(ctx context.Context, msg *domain.Message) error
-	GetMessages(ctx context.Context, conversationID uint64, limit, offset int) ([]domain.Message, error)
+	GetMessagesBefore(ctx context.Context, conversationID, viewerID, beforeID uint64, since time.Time, limit int) ([]domain.Message, error)
+	GetMessagesAfter(ctx context.Context, conversationID, viewerID, afterID uint64, since time.Time, limit int) ([]domain.Message, error)
 	GetMessageByID(ctx context.Context, id uint64) (*domain.Message, error)
+	// GetMessageForUpdate locks the message, it must be called inside a transaction.
+	GetMessageForUpdate(ctx context.Context, id uint64) (*domain.Message, error)
+	GetMessagesByIDs(ctx context.Context, ids []uint64) ([]domain.Message, error)
 	UpdateMessage(ctx context.Context, msg *domain.Message) error
 	DeleteMessage(ctx context.Context, id uint64) error
 
+	// Attachments
+	AddAttachment(ctx context.Context, att *domain.Attachment) error
+	GetAttachments(ctx context.Context, messageIDs []uint64) (map[uint64][]domain.Attachment, error)
+	// DeleteAttachments removes the attachments of a message and returns their storage keys.
+	DeleteAttachments(ctx context.Context, messageID uint64) ([]string, error)
+
+	// Edits and per-user deletes
+	AddMessageEdit(ctx context.Context, edit *domain.MessageEdit) error
+	GetMessageEdits(ctx context.Context, messageID uint64) ([]domain.MessageEdit, error)
+	HideMessage(ctx context.Context, messageID, userID uint64) error
+	IsMessageHidden(ctx context.Context, messageID, userID uint64) (bool, error)
+	GetHiddenMessageIDs(ctx context.Context, userID uint64, messageIDs []uint64) (map[uint64]bool, error)
+
+	// Reactions
+	AddReaction(ctx context.Context, reaction *domain.Reaction) (bool, error)
+	RemoveReaction(ctx context.Context, messageID, userID uint64, emoji string) (bool, error)
+	GetReactions(ctx context.Context, messageID uint64) ([]domain.Reaction, error)
+	GetReactionCounts(ctx context.Context, messageIDs []uint64, viewerID uint64) (map[uint64][]domain.ReactionCount, error)
+
+	// Read receipts
+	MarkRead(ctx context.Context, receipt *domain.ReadReceipt) (bool, error)
+	GetUnreadCounts(ctx context.Context, userID uint64, limit int) (map[uint64]int, error)
+	GetReadReceipts(ctx context.Context, conversationID, messageID uint64) ([]domain.ReadReceipt, error)
+
 	// DM specific
 	GetDMConversation(ctx context.Context, user1ID, user2ID uint64) (*domain.Conversation, error)
 	CreateDMConversation(ctx context.Context, user1ID, user2ID uint64, convID uint64) error
@@ -0,0 +1,337 @@
+package media
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	"github.com/lib/pq"
+)
+
+func (r *mediaRepo) Create(ctx context.Context, obj *domain.MediaObject) error {
+	processing := domain.ProcessingNone
+	if domain.ProcessableImage(obj.MimeType) {
+		processing = domain.ProcessingPending
+	}
+
+	query := `INSERT INTO media_objects (owner_id, storage_key, purpose, mime_type, size_bytes, checksum, processing)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)
+			  ON CONFLICT (storage_key) DO UPDATE SET storage_key = EXCLUDED.storage_key
+			  RETURNING id, owner_id, purpose, mime_type, size_bytes, checksum, ref_count, created_at, processing, width, height, placeholder`
+
+	return r.execer().QueryRowContext(ctx, query, obj.OwnerID, obj.StorageKey, obj.Purpose, obj.MimeType, obj.SizeBytes, obj.Checksum, processing).
+		Scan(&obj.ID, &obj.OwnerID, &obj.Purpose, &obj.MimeType, &obj.SizeBytes, &obj.Checksum, &obj.RefCount, &obj.CreatedAt,
+			&obj.Processing, &obj.Width, &obj.Height, &obj.Placeholder)
+}
+
+func (r *mediaRepo) GetByKey(ctx context.Context, storageKey string) (*domain.MediaObject, error) {
+	query := `SELECT id, owner_id, storage_key, purpose, mime_type, size_bytes, checksum, ref_count, created_at,
+			  		processing, width, height, placeholder
+			  FROM media_objects WHERE storage_key = $1 AND deleted_at IS NULL`
+
+	var m domain.MediaObject
+	err := r.execer().QueryRowContext(ctx, query, storageKey).Scan(
+		&m.ID, &m.OwnerID, &m.StorageKey, &m.Purpose, &m.MimeType, &m.SizeBytes, &m.Checksum, &m.RefCount, &m.CreatedAt,
+		&m.Processing, &m.Width, &m.Height, &m.Placeholder,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *mediaRepo) GetByKeys(ctx context.Context, storageKeys []string) (map[string]domain.MediaObject, error) {
+	objs := make(map[string]domain.MediaObject)
+	if len(storageKeys) == 0 {
+		return objs, nil
+	}
+
+	query := `SELECT id, owner_id, storage_key, purpose, mime_type, size_bytes, checksum, ref_count, created_at,
+			  		processing, width, height, placeholder
+			  FROM media_objects WHERE storage_key = ANY($1) AND deleted_at IS NULL`
+
+	rows, err := r.execer().QueryContext(ctx, query, pq.Array(storageKeys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m domain.MediaObject
+		if err := rows.Scan(
+			&m.ID, &m.OwnerID, &m.StorageKey, &m.Purpose, &m.MimeType, &m.SizeBytes, &m.Checksum, &m.RefCount, &m.CreatedAt,
+			&m.Processing, &m.Width, &m.Height, &m.Placeholder,
+		); err != nil {
+			return nil, err
+		}
+		objs[m.StorageKey] = m
+	}
+	return objs, rows.Err()
+}
+
+func (r *mediaRepo) ClaimPendingImages(ctx context.Context, limit int, staleAfter time.Duration) ([]domain.MediaObject, error) {
+	query := `UPDATE media_objects SET processing_started_at = NOW(), processing_attempts = processing_attempts + 1
+			  WHERE id IN (
+			  	SELECT id FROM media_objects
+			  	WHERE processing = 'pending' AND deleted_at IS NULL
+			  	AND (processing_started_at IS NULL OR processing_started_at < NOW() - make_interval(secs => $2))
+			  	ORDER BY id
+			  	LIMIT $1
+			  	FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING id, owner_id, storage_key, purpose, mime_type, size_bytes, checksum, ref_count, created_at, processing`
+
+	rows, err := r.execer().QueryContext(ctx, query, limit, staleAfter.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objs []domain.MediaObject
+	for rows.Next() {
+		var m domain.MediaObject
+		if err := rows.Scan(
+			&m.ID, &m.OwnerID, &m.StorageKey, &m.Purpose, &m.MimeType, &m.SizeBytes, &m.Checksum, &m.RefCount, &m.CreatedAt,
+			&m.Processing,
+		); err != nil {
+			return nil, err
+		}
+		objs = append(objs, m)
+	}
+	return objs, rows.Err()
+}
+
+func (r *mediaRepo) SaveProcessed(ctx context.Context, obj *domain.MediaObject) error {
+	query := `UPDATE media_objects
+			  SET processing = 'ready', processing_started_at = NULL,
+			  	size_bytes = $2, checksum = $3, width = $4, height = $5, placeholder = $6
+			  WHERE id = $1`
+	if _, err := r.execer().ExecContext(ctx, query, obj.ID, obj.SizeBytes, obj.Checksum, obj.Width, obj.Height, obj.Placeholder); err != nil {
+		return err
+	}
+
+	// attachments copy these when they are created, refresh the ones sent before processing finished
+	query = `UPDATE message_attachments SET size_bytes = $2, width = $3, height = $4 WHERE storage_key = $1`
+	_, err := r.execer().ExecContext(ctx, query, obj.StorageKey, obj.SizeBytes, obj.Width, obj.Height)
+	return err
+}
+
+func (r *mediaRepo) ReleaseProcessing(ctx context.Context, id uint64, maxAttempts int) error {
+	query := `UPDATE media_objects
+			  SET processing = CASE WHEN processing_attempts >= $2 THEN 'failed' ELSE 'pending' END,
+			  	processing_started_at = NULL
+			  WHERE id = $1`
+	_, err := r.execer().ExecContext(ctx, query, id, maxAttempts)
+	return err
+}
+
+func (r *mediaRepo) AddRefs(ctx context.Context, storageKeys []string, delta int) error {
+	if len(storageKeys) == 0 {
+		return nil
+	}
+
+	query := `UPDATE media_objects m SET ref_count = GREATEST(m.ref_count + $2 * k.n, 0)
+			  FROM (SELECT key, COUNT(*) AS n FROM unnest($1::text[]) AS key GROUP BY key) k
+			  WHERE m.storage_key = k.key AND m.deleted_at IS NULL`
+	res, err := r.execer().ExecContext(ctx, query, pq.Array(storageKeys), delta)
+	if err != nil {
+		return err
+	}
+	if delta <= 0 {
+		return nil
+	}
+
+	// the row lock taken by the update keeps the objects from being deleted until the
+	// transaction ends, but one may have been deleted since the caller looked it up
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	distinct := make(map[string]struct{}, len(storageKeys))
+	for _, key := range storageKeys {
+		distinct[key] = struct{}{}
+	}
+	if int(n) < len(distinct) {
+		return ErrMediaNotFound
+	}
+	return nil
+}
+
+func (r *mediaRepo) MarkDeleted(ctx context.Context, storageKey string, ownerID uint64) (bool, error) {
+	query := `UPDATE media_objects SET deleted_at = NOW()
+			  WHERE storage_key = $1 AND owner_id = $2 AND ref_count = 0 AND deleted_at IS NULL`
+
+	res, err := r.execer().ExecContext(ctx, query, storageKey, ownerID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *mediaRepo) GetDeleted(ctx context.Context, olderThan time.Duration, limit int) ([]domain.MediaObject, error) {
+	query := `SELECT id, owner_id, storage_key, mime_type
+			  FROM media_objects
+			  WHERE deleted_at < NOW() - make_interval(secs => $1)
+			  ORDER BY deleted_at
+			  LIMIT $2`
+
+	rows, err := r.execer().QueryContext(ctx, query, olderThan.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objs []domain.MediaObject
+	for rows.Next() {
+		var m domain.MediaObject
+		if err := rows.Scan(&m.ID, &m.OwnerID, &m.StorageKey, &m.MimeType); err != nil {
+			return nil, err
+		}
+		objs = append(objs, m)
+	}
+	return objs, rows.Err()
+}
+
+func (r *mediaRepo) Purge(ctx context.Context, storageKey string) error {
+	query := `DELETE FROM media_objects WHERE storage_key = $1 AND deleted_at IS NOT NULL`
+	_, err := r.execer().ExecContext(ctx, query, storageKey)
+	return err
+}
+
+func (r *mediaRepo) CreateUpload(ctx context.Context, up *domain.ResumableUpload) error {
+	query := `INSERT INTO resumable_uploads (id, user_id, object_key, multipart_id, purpose, mime_type, size_bytes, chunk_size, expires_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			  RETURNING offset_bytes, status, created_at, updated_at`
+
+	return r.execer().QueryRowContext(ctx, query, up.ID, up.UserID, up.ObjectKey, up.MultipartID, up.Purpose, up.MimeType, up.Size, up.ChunkSize, up.ExpiresAt).
+		Scan(&up.Offset, &up.Status, &up.CreatedAt, &up.UpdatedAt)
+}
+
+func (r *mediaRepo) GetUpload(ctx context.Context, id string) (*domain.ResumableUpload, error) {
+	query := `SELECT id, user_id, object_key, multipart_id, purpose, mime_type, size_bytes, chunk_size, offset_bytes, status, created_at, updated_at, expires_at, hash_state
+			  FROM resumable_uploads WHERE id = $1`
+
+	var up domain.ResumableUpload
+	err := r.execer().QueryRowContext(ctx, query, id).Scan(
+		&up.ID, &up.UserID, &up.ObjectKey, &up.MultipartID, &up.Purpose, &up.MimeType, &up.Size, &up.ChunkSize,
+		&up.Offset, &up.Status, &up.CreatedAt, &up.UpdatedAt, &up.ExpiresAt, &up.HashState,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &up, nil
+}
+
+func (r *mediaRepo) ClaimChunk(ctx context.Context, id string, offset int64, claimID string, until time.Time) (bool, error) {
+	query := `UPDATE resumable_uploads SET claim_id = $3, claimed_until = $4
+			  WHERE id = $1 AND offset_bytes = $2 AND status = 'active'
+			  AND (claimed_until IS NULL OR claimed_until < NOW())`
+
+	res, err := r.execer().ExecContext(ctx, query, id, offset, claimID, until)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *mediaRepo) ReleaseChunk(ctx context.Context, id, claimID string) error {
+	query := `UPDATE resumable_uploads SET claim_id = NULL, claimed_until = NULL WHERE id = $1 AND claim_id = $2`
+	_, err := r.execer().ExecContext(ctx, query, id, claimID)
+	return err
+}
+
+func (r *mediaRepo) AdvanceUpload(ctx context.Context, id, claimID string, from, to int64, hashState []byte, expiresAt time.Time) (bool, error) {
+	query := `UPDATE resumable_uploads SET offset_bytes = $4, hash_state = $5, expires_at = $6, updated_at = NOW(),
+			  	claim_id = NULL, claimed_until = NULL
+			  WHERE id = $1 AND claim_id = $2 AND offset_bytes = $3 AND status = 'active'`
+
+	res, err := r.execer().ExecContext(ctx, query, id, claimID, from, to, hashState, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *mediaRepo) SetUploadStatus(ctx context.Context, id string, status domain.UploadStatus) error {
+	query := `UPDATE resumable_uploads SET status = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.execer().ExecContext(ctx, query, id, status)
+	return err
+}
+
+func (r *mediaRepo) SaveUploadPart(ctx context.Context, part *domain.UploadPart) error {
+	query := `INSERT INTO resumable_upload_parts (upload_id, part_number, etag, size_bytes)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (upload_id, part_number) DO UPDATE SET etag = EXCLUDED.etag, size_bytes = EXCLUDED.size_bytes`
+	_, err := r.execer().ExecContext(ctx, query, part.UploadID, part.PartNumber, part.ETag, part.Size)
+	return err
+}
+
+func (r *mediaRepo) GetUploadParts(ctx context.Context, uploadID string) ([]domain.UploadPart, error) {
+	query := `SELECT upload_id, part_number, etag, size_bytes
+			  FROM resumable_upload_parts WHERE upload_id = $1 ORDER BY part_number`
+
+	rows, err := r.execer().QueryContext(ctx, query, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parts []domain.UploadPart
+	for rows.Next() {
+		var p domain.UploadPart
+		if err := rows.Scan(&p.UploadID, &p.PartNumber, &p.ETag, &p.Size); err != nil {
+			return nil, err
+		}
+		parts = append(parts, p)
+	}
+	return parts, rows.Err()
+}
+
+func (r *mediaRepo) LockUploads(ctx context.Context, userID uint64) error {
+	query := `SELECT pg_advisory_xact_lock(hashtext('resumable_uploads'), hashtext($1::text))`
+	_, err := r.execer().ExecContext(ctx, query, userID)
+	return err
+}
+
+func (r *mediaRepo) GetActiveUploadUsage(ctx context.Context, userID uint64) (int, int64, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(size_bytes), 0)
+			  FROM resumable_uploads WHERE user_id = $1 AND status = 'active' AND expires_at > NOW()`
+
+	var (
+		count int
+		bytes int64
+	)
+	err := r.execer().QueryRowContext(ctx, query, userID).Scan(&count, &bytes)
+	return count, bytes, err
+}
+
+func (r *mediaRepo) ExpireUploads(ctx context.Context, limit int) ([]domain.ResumableUpload, error) {
+	query := `UPDATE resumable_uploads SET status = 'aborted', updated_at = NOW()
+			  WHERE id IN (
+			  	SELECT id FROM resumable_uploads
+			  	WHERE status = 'active' AND expires_at < NOW()
+			  	ORDER BY expires_at
+			  	LIMIT $1
+			  	FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING id, user_id, object_key, multipart_id`
+
+	rows, err := r.execer().QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []domain.ResumableUpload
+	for rows.Next() {
+		up := domain.ResumableUpload{Status: domain.UploadStatusAborted}
+		if err := rows.Scan(&up.ID, &up.UserID, &up.ObjectKey, &up.MultipartID); err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, up)
+	}
+	return uploads, rows.Err()
+}
@@ -0,0 +1,69 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
+
+// ErrMediaNotFound is returned when a reference is added to an object that was deleted.
+var ErrMediaNotFound = errors.New("media not found")
+
+type MediaStore interface {
+	WithTx(tx *sql.Tx) *mediaRepo
+
+	// Create records the object; recording the same storage key twice is a no-op
+	// that loads the existing row.
+	Create(ctx context.Context, obj *domain.MediaObject) error
+	GetByKey(ctx context.Context, storageKey string) (*domain.MediaObject, error)
+	GetByKeys(ctx context.Context, storageKeys []string) (map[string]domain.MediaObject, error)
+	// AddRefs adds delta to the reference count of every key, once per occurrence. When
+	// adding references it fails with ErrMediaNotFound if any of the objects is gone.
+	AddRefs(ctx context.Context, storageKeys []string, delta int) error
+	// MarkDeleted leaves a tombstone on the record if the owner matches and nothing
+	// references the object any more, and reports whether it did. Tombstoned objects are
+	// not found by the lookups above and take no new references; the record stays until
+	// the stored object is gone, so a failed storage delete can be retried.
+	MarkDeleted(ctx context.Context, storageKey string, ownerID uint64) (bool, error)
+	// GetDeleted returns up to limit tombstoned objects marked more than olderThan ago.
+	GetDeleted(ctx context.Context, olderThan time.Duration, limit int) ([]domain.MediaObject, error)
+	// Purge removes the record of a tombstoned object.
+	Purge(ctx context.Context, storageKey string) error
+
+	// Image processing
+	// ClaimPendingImages takes up to limit images waiting for thumbnails, including ones
+	// claimed more than staleAfter ago by a worker that never finished.
+	ClaimPendingImages(ctx context.Context, limit int, staleAfter time.Duration) ([]domain.MediaObject, error)
+	// SaveProcessed marks an image ready and stores its dimensions, placeholder and the
+	// size and checksum of the stripped original, also on the attachments using it.
+	SaveProcessed(ctx context.Context, obj *domain.MediaObject) error
+	// ReleaseProcessing returns a claimed image to the queue after a failure, or marks it
+	// failed once it has been tried maxAttempts times.
+	ReleaseProcessing(ctx context.Context, id uint64, maxAttempts int) error
+
+	// Resumable uploads
+	CreateUpload(ctx context.Context, up *domain.ResumableUpload) error
+	GetUpload(ctx context.Context, id string) (*domain.ResumableUpload, error)
+	// ClaimChunk reserves the chunk at offset for one request until the claim expires.
+	// It reports false when the offset has moved or another request holds the claim.
+	ClaimChunk(ctx context.Context, id string, offset int64, claimID string, until time.Time) (bool, error)
+	// ReleaseChunk drops a claim whose chunk was not stored.
+	ReleaseChunk(ctx context.Context, id, claimID string) error
+	// AdvanceUpload moves the offset from one value to another, pushes the expiry forward
+	// and drops the claim. It reports false when the claim was lost, i.e. it expired
+	// and another request took over the chunk.
+	AdvanceUpload(ctx context.Context, id, claimID string, from, to int64, hashState []byte, expiresAt time.Time) (bool, error)
+	SetUploadStatus(ctx context.Context, id string, status domain.UploadStatus) error
+	SaveUploadPart(ctx context.Context, part *domain.UploadPart) error
+	GetUploadParts(ctx context.Context, uploadID string) ([]domain.UploadPart, error)
+	// LockUploads serializes the creation of a user's uploads until the transaction ends,
+	// so concurrent requests cannot pass the quota together.
+	LockUploads(ctx context.Context, userID uint64) error
+	// GetActiveUploadUsage returns the number of active uploads of a user and their declared bytes.
+	GetActiveUploadUsage(ctx context.Context, userID uint64) (count int, bytes int64, err error)
+	// ExpireUploads marks up to limit active uploads past their expiry as aborted and returns them.
+	ExpireUploads(ctx context.Context, limit int) ([]domain.ResumableUpload, error)
+}
@@ -0,0 +1,36 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/rs/zerolog"
+)
+
+type mediaRepo struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	logger zerolog.Logger
+}
+
+func NewMediaRepo(db *sql.DB, logger zerolog.Logger) *mediaRepo {
+	return &mediaRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *mediaRepo) WithTx(tx *sql.Tx) *mediaRepo {
+	return &mediaRepo{db: r.db, tx: tx, logger: r.logger}
+}
+
+func (r *mediaRepo) execer() interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
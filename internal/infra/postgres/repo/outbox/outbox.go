@@ -0,0 +1,73 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	"github.com/lib/pq"
+)
+
+func (r *outboxRepo) Insert(ctx context.Context, evt *domain.OutboxEvent) error {
+	query := `INSERT INTO outbox_events (idempotency_key, topic, event_type, aggregate_id, payload)
+			  VALUES ($1, $2, $3, $4, $5)
+			  RETURNING id, created_at`
+
+	err := r.execer().QueryRowContext(ctx, query, evt.IdempotencyKey, evt.Topic, evt.EventType, evt.AggregateID, evt.Payload).
+		Scan(&evt.ID, &evt.CreatedAt)
+	return err
+}
+
+func (r *outboxRepo) FetchPending(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	query := `SELECT id, idempotency_key, topic, event_type, aggregate_id, payload, attempts, last_error, created_at
+			  FROM outbox_events
+			  WHERE published_at IS NULL
+			  ORDER BY id
+			  LIMIT $1
+			  FOR UPDATE SKIP LOCKED`
+
+	rows, err := r.execer().QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var e domain.OutboxEvent
+		if err := rows.Scan(&e.ID, &e.IdempotencyKey, &e.Topic, &e.EventType, &e.AggregateID, &e.Payload, &e.Attempts, &e.LastError, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (r *outboxRepo) MarkPublished(ctx context.Context, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	arr := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		arr = append(arr, int64(id))
+	}
+
+	query := `UPDATE outbox_events SET published_at = NOW(), attempts = attempts + 1 WHERE id = ANY($1)`
+	_, err := r.execer().ExecContext(ctx, query, pq.Array(arr))
+	return err
+}
+
+func (r *outboxRepo) MarkFailed(ctx context.Context, ids []uint64, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	arr := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		arr = append(arr, int64(id))
+	}
+
+	query := `UPDATE outbox_events SET attempts = attempts + 1, last_error = $1 WHERE id = ANY($2)`
+	_, err := r.execer().ExecContext(ctx, query, reason, pq.Array(arr))
+	return err
+}
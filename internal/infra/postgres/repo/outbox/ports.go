@@ -0,0 +1,20 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
+
+type OutboxStore interface {
+	// events must be written in the same transaction as the change they describe
+	WithTx(tx *sql.Tx) *outboxRepo
+
+	Insert(ctx context.Context, evt *domain.OutboxEvent) error
+
+	// locks the oldest unpublished events, must be called inside a transaction
+	FetchPending(ctx context.Context, limit int) ([]domain.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uint64) error
+	MarkFailed(ctx context.Context, ids []uint64, reason string) error
+}
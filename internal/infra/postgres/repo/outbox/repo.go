@@ -0,0 +1,36 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/rs/zerolog"
+)
+
+type outboxRepo struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	logger zerolog.Logger
+}
+
+func NewOutboxRepo(db *sql.DB, logger zerolog.Logger) *outboxRepo {
+	return &outboxRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *outboxRepo) WithTx(tx *sql.Tx) *outboxRepo {
+	return &outboxRepo{db: r.db, tx: tx, logger: r.logger}
+}
+
+func (r *outboxRepo) execer() interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
@@ -15,6 +15,8 @@ type SessionStore interface {
 	// lists sessions where refresh is still valid (or revoked_at is null)
 	GetAllValidSessionsByUserId(ctx context.Context, userID uint64) ([]domain.UserSession, error)
 
+	GetByID(ctx context.Context, sessionID uint64) (*domain.UserSession, error)
+
 	// token lookups (index tokens in DB)
 	GetByAccessToken(ctx context.Context, accessToken string) (*domain.UserSession, error)
 	GetByRefreshToken(ctx context.Context, refreshToken string) (*domain.UserSession, error)
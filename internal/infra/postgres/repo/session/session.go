@@ -46,6 +46,23 @@ func (r *sessionRepo) GetAllValidSessionsByUserId(ctx context.Context, userID ui
 	return sessions, nil
 }
 
+func (r *sessionRepo) GetByID(ctx context.Context, sessionID uint64) (*domain.UserSession, error) {
+	query := `SELECT id, user_id, refresh_token, refresh_token_expires_at, access_token, access_token_expires_at,
+				last_used_at, ip_address, user_agent, device, created_at, updated_at, revoked_at
+				FROM sessions WHERE id = $1`
+
+	var result domain.UserSession
+
+	err := r.execer().QueryRowContext(ctx, query, sessionID).Scan(&result.ID, &result.UserID, &result.RefreshToken, &result.RefreshTokenExp, &result.AccessToken,
+		&result.AccessTokenExp, &result.LastUsedAt, &result.IPAddress, &result.UserAgent,
+		&result.Device, &result.CreatedAt, &result.UpdatedAt, &result.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 func (r *sessionRepo) GetByAccessToken(ctx context.Context, accessToken string) (*domain.UserSession, error) {
 	query := `SELECT id, user_id, refresh_token, refresh_token_expires_at, access_token, access_token_expires_at, 
 				last_used_at, ip_address, user_agent, device, created_at, updated_at, revoked_at
@@ -21,6 +21,7 @@ type UserStore interface {
 	UpdatePassword(ctx context.Context, userID uint64, passwordHash string) error
 	AddProfileMedia(ctx context.Context, userID uint64, mediaKey string, isPrimary bool) error
 	GetProfileMedia(ctx context.Context, userID uint64) ([]domain.UserProfileMedia, error)
-	DeleteProfileMedia(ctx context.Context, userID uint64, mediaID uint64) error
+	// DeleteProfileMedia returns the storage key of the removed image.
+	DeleteProfileMedia(ctx context.Context, userID uint64, mediaID uint64) (string, error)
 	SetPrimaryProfileMedia(ctx context.Context, userID uint64, mediaID uint64) error
 }
@@ -140,10 +140,12 @@ func (r *userRepo) GetProfileMedia(ctx context.Context, userID uint64) ([]domain
 	return media, nil
 }
 
-func (r *userRepo) DeleteProfileMedia(ctx context.Context, userID uint64, mediaID uint64) error {
-	query := `DELETE FROM user_profile_images WHERE id = $1 AND user_id = $2`
-	_, err := r.execer().ExecContext(ctx, query, mediaID, userID)
-	return err
+func (r *userRepo) DeleteProfileMedia(ctx context.Context, userID uint64, mediaID uint64) (string, error) {
+	query := `DELETE FROM user_profile_images WHERE id = $1 AND user_id = $2 RETURNING image_key`
+
+	var key string
+	err := r.execer().QueryRowContext(ctx, query, mediaID, userID).Scan(&key)
+	return key, err
 }
 
 func (r *userRepo) SetPrimaryProfileMedia(ctx context.Context, userID uint64, mediaID uint64) error {
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// FallbackLimiter uses primary and switches to fallback for as long as primary fails,
+// so an unavailable Redis degrades limits to per instance instead of failing requests.
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	degraded atomic.Bool
+	logger   zerolog.Logger
+}
+
+func NewFallbackLimiter(primary, fallback Limiter, logger zerolog.Logger) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, fallback: fallback, logger: logger}
+}
+
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	res, err := l.primary.Allow(ctx, key, rule)
+	if err == nil {
+		if l.degraded.CompareAndSwap(true, false) {
+			l.logger.Info().Msg("rate limiter recovered")
+		}
+		return res, nil
+	}
+
+	if l.degraded.CompareAndSwap(false, true) {
+		l.logger.Error().Err(err).Msg("rate limiter failed, falling back to in-memory limits")
+	}
+	return l.fallback.Allow(ctx, key, rule)
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type counter struct {
+	window time.Duration
+	index  int64 // number of the current fixed window since the epoch
+	prev   int64
+	cur    int64
+}
+
+// MemoryLimiter keeps the counters in process, so limits are per instance.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		counters:  make(map[string]*counter),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, rule Rule) (Result, error) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+
+	index := now.UnixNano() / int64(rule.Window)
+	elapsed := time.Duration(now.UnixNano() % int64(rule.Window))
+
+	c, ok := l.counters[key]
+	if !ok || c.window != rule.Window {
+		c = &counter{window: rule.Window, index: index}
+		l.counters[key] = c
+	}
+	switch {
+	case c.index == index-1:
+		c.index, c.prev, c.cur = index, c.cur, 0
+	case c.index < index-1:
+		c.index, c.prev, c.cur = index, 0, 0
+	}
+
+	allowed := Weighted(c.prev, c.cur, elapsed, rule.Window)+1 <= float64(rule.Limit)
+	res := NewResult(rule, c.prev, c.cur, elapsed, allowed)
+	if allowed {
+		c.cur++
+	}
+	return res, nil
+}
+
+// sweep drops counters that no longer affect any decision.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, c := range l.counters {
+		if now.UnixNano()/int64(c.window) > c.index+1 {
+			delete(l.counters, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,56 @@
+// Package ratelimit counts requests per key with a sliding window: the count of the
+// previous fixed window is weighted by how much of it still overlaps the sliding one,
+// which smooths out bursts at window boundaries at the cost of two counters per key.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the current fixed window ends
+	RetryAfter time.Duration // zero when allowed
+}
+
+type Limiter interface {
+	// Allow counts a request for key unless it would exceed the rule.
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// Weighted is the sliding window count given the counts of the previous and current
+// fixed windows and how far into the current one now is.
+func Weighted(prev, cur int64, elapsed, window time.Duration) float64 {
+	return float64(prev)*(1-float64(elapsed)/float64(window)) + float64(cur)
+}
+
+// NewResult builds the result of a request given the window counts before it was counted.
+func NewResult(rule Rule, prev, cur int64, elapsed time.Duration, allowed bool) Result {
+	res := Result{Allowed: allowed, Limit: rule.Limit, Reset: rule.Window - elapsed}
+
+	count := Weighted(prev, cur, elapsed, rule.Window)
+	if allowed {
+		count++
+	}
+	res.Remaining = max(rule.Limit-int(math.Ceil(count)), 0)
+
+	if !allowed {
+		if cur+1 > int64(rule.Limit) || prev == 0 {
+			res.RetryAfter = res.Reset
+		} else {
+			// wait until enough of the previous window has slid out
+			need := rule.Window - time.Duration(float64(rule.Window)*float64(int64(rule.Limit)-cur-1)/float64(prev))
+			res.RetryAfter = max(need-elapsed, time.Millisecond)
+		}
+	}
+	return res
+}
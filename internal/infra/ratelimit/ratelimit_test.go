@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewResult(t *testing.T) {
+	rule := Rule{Limit: 10, Window: time.Minute}
+
+	tests := []struct {
+		name    string
+		prev    int64
+		cur     int64
+		elapsed time.Duration
+		allowed bool
+		want    Result
+	}{
+		{
+			name:    "first request",
+			allowed: true,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Minute},
+		},
+		{
+			name:    "previous window weighted by overlap",
+			prev:    10,
+			elapsed: 30 * time.Second,
+			allowed: true,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 4, Reset: 30 * time.Second},
+		},
+		{
+			name:    "partial count rounds remaining down",
+			prev:    3,
+			elapsed: 30 * time.Second,
+			allowed: true,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 7, Reset: 30 * time.Second},
+		},
+		{
+			name:    "current window full waits for the next one",
+			cur:     10,
+			elapsed: 15 * time.Second,
+			want:    Result{Limit: 10, Reset: 45 * time.Second, RetryAfter: 45 * time.Second},
+		},
+		{
+			name:    "previous window must slide out",
+			prev:    10,
+			cur:     5,
+			elapsed: 30 * time.Second,
+			want:    Result{Limit: 10, Reset: 30 * time.Second, RetryAfter: 6 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewResult(rule, tt.prev, tt.cur, tt.elapsed, tt.allowed)
+			if got != tt.want {
+				t.Errorf("NewResult() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	rule := Rule{Limit: 3, Window: time.Minute}
+	start := time.Unix(600, 0) // the start of a window
+
+	steps := []struct {
+		name       string
+		key        string
+		at         time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{name: "first", key: "a", at: 0, allowed: true, remaining: 2},
+		{name: "second", key: "a", at: time.Second, allowed: true, remaining: 1},
+		{name: "third", key: "a", at: 2 * time.Second, allowed: true, remaining: 0},
+		{name: "over the limit", key: "a", at: 3 * time.Second, retryAfter: 57 * time.Second},
+		{name: "other key", key: "b", at: 3 * time.Second, allowed: true, remaining: 2},
+		{name: "half of the previous window counts", key: "a", at: 90 * time.Second, allowed: true, remaining: 0},
+		{name: "still weighted", key: "a", at: 91 * time.Second, retryAfter: 9 * time.Second},
+		{name: "old windows forgotten", key: "a", at: 181 * time.Second, allowed: true, remaining: 2},
+	}
+
+	l := NewMemoryLimiter()
+	for _, s := range steps {
+		l.now = func() time.Time { return start.Add(s.at) }
+
+		res, err := l.Allow(context.Background(), s.key, rule)
+		if err != nil {
+			t.Fatalf("%s: Allow() error = %v", s.name, err)
+		}
+		if res.Allowed != s.allowed || res.Remaining != s.remaining || res.RetryAfter != s.retryAfter {
+			t.Errorf("%s: Allow() = %+v, want allowed %v, remaining %d, retry after %v",
+				s.name, res, s.allowed, s.remaining, s.retryAfter)
+		}
+	}
+}
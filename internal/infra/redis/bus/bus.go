@@ -0,0 +1,84 @@
+package redisBus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/eventbus"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// RedisBus publishes chat events over Redis pub/sub so that every instance
+// receives the events of the conversations its connected users participate in.
+type RedisBus struct {
+	rdb    *redis.Client
+	pubsub *redis.PubSub
+	logger zerolog.Logger
+}
+
+func NewRedisBus(rdb *redis.Client, logger zerolog.Logger) *RedisBus {
+	return &RedisBus{
+		rdb:    rdb,
+		pubsub: rdb.Subscribe(context.Background()),
+		logger: logger,
+	}
+}
+
+func (b *RedisBus) Publish(ctx context.Context, evt domain.Event) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	pipe := b.rdb.Pipeline()
+	for _, ch := range eventbus.Channels(evt) {
+		pipe.Publish(ctx, ch, data)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis publish: %w", err)
+	}
+	return nil
+}
+
+func (b *RedisBus) Subscribe(ctx context.Context, channels ...string) error {
+	if len(channels) == 0 {
+		return nil
+	}
+	return b.pubsub.Subscribe(ctx, channels...)
+}
+
+func (b *RedisBus) Unsubscribe(ctx context.Context, channels ...string) error {
+	if len(channels) == 0 {
+		return nil
+	}
+	return b.pubsub.Unsubscribe(ctx, channels...)
+}
+
+func (b *RedisBus) Run(ctx context.Context, handler eventbus.Handler) error {
+	ch := b.pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			var evt domain.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &evt); err != nil {
+				b.logger.Error().Err(err).Str("channel", msg.Channel).Msg("failed to decode chat event")
+				continue
+			}
+			handler(evt)
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *RedisBus) Close() error {
+	return b.pubsub.Close()
+}
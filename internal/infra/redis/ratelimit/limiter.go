@@ -0,0 +1,58 @@
+package redisLimiter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// allowScript applies the sliding window of the ratelimit package atomically. It returns
+// whether the request was allowed and the previous and current window counts before it.
+var allowScript = redis.NewScript(`
+local prev = tonumber(redis.call('GET', KEYS[1]) or '0')
+local cur = tonumber(redis.call('GET', KEYS[2]) or '0')
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+
+local allowed = 0
+if prev * (1 - elapsed / window) + cur + 1 <= limit then
+	allowed = 1
+	redis.call('INCR', KEYS[2])
+	redis.call('PEXPIRE', KEYS[2], window * 2)
+end
+return {allowed, prev, cur}
+`)
+
+// RedisLimiter shares the counters between all instances.
+type RedisLimiter struct {
+	rdb *redis.Client
+}
+
+func NewRedisLimiter(rdb *redis.Client) *RedisLimiter {
+	return &RedisLimiter{rdb: rdb}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, rule ratelimit.Rule) (ratelimit.Result, error) {
+	// the clock of this instance is used so the window is the same across the script
+	// and the result; instances are expected to be roughly in sync
+	now := time.Now().UnixMilli()
+	window := rule.Window.Milliseconds()
+	index := now / window
+	elapsed := now % window
+
+	keys := []string{
+		"ratelimit:" + key + ":" + strconv.FormatInt(index-1, 10),
+		"ratelimit:" + key + ":" + strconv.FormatInt(index, 10),
+	}
+	vals, err := allowScript.Run(ctx, l.rdb, keys, rule.Limit, window, elapsed).Int64Slice()
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("redis rate limit: %w", err)
+	}
+
+	return ratelimit.NewResult(rule, vals[1], vals[2], time.Duration(elapsed)*time.Millisecond, vals[0] == 1), nil
+}
@@ -3,10 +3,39 @@ package redisStore
 import (
 	"context"
 	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
+
+// OTPScope is what failed verification attempts are counted against.
+type OTPScope string
+
+const (
+	OTPScopeEmail OTPScope = "email"
+	OTPScopeIP    OTPScope = "ip"
 )
 
 type OTPStore interface {
 	SaveEmailCode(ctx context.Context, email string, codeHash string, ttl time.Duration) error
 	GetEmailCodeHash(ctx context.Context, email string) (string, error)
 	DeleteEmailCode(ctx context.Context, email string) error
+	// StartResendCooldown starts a cooldown of ttl for sending email a new code. When a
+	// cooldown is already running it returns the time left instead.
+	StartResendCooldown(ctx context.Context, email string, ttl time.Duration) (remaining time.Duration, err error)
+
+	// IncrFailedAttempts counts an attempt for id and returns the attempts within the
+	// window, which starts at the first one.
+	IncrFailedAttempts(ctx context.Context, scope OTPScope, id string, window time.Duration) (int64, error)
+	ResetFailedAttempts(ctx context.Context, scope OTPScope, id string) error
+	// Lock blocks verification for id for ttl and clears its failure count.
+	Lock(ctx context.Context, scope OTPScope, id string, ttl time.Duration) error
+	// LockedFor returns how long id stays locked, zero when it is not.
+	LockedFor(ctx context.Context, scope OTPScope, id string) (time.Duration, error)
+}
+
+type UploadIntentStore interface {
+	SaveUploadIntent(ctx context.Context, intent *domain.UploadIntent, ttl time.Duration) error
+	// GetUploadIntent returns nil when the intent does not exist or has expired.
+	GetUploadIntent(ctx context.Context, id string) (*domain.UploadIntent, error)
+	DeleteUploadIntent(ctx context.Context, id string) error
 }
@@ -34,3 +34,59 @@ func (s *OTPRedisStore) GetEmailCodeHash(ctx context.Context, email string) (str
 func (s *OTPRedisStore) DeleteEmailCode(ctx context.Context, email string) error {
 	return s.rdb.Del(ctx, s.key(email)).Err()
 }
+
+func (s *OTPRedisStore) StartResendCooldown(ctx context.Context, email string, ttl time.Duration) (time.Duration, error) {
+	key := "otp:cooldown:" + email
+
+	ok, err := s.rdb.SetNX(ctx, key, 1, ttl).Result()
+	if err != nil || ok {
+		return 0, err
+	}
+
+	remaining, err := s.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	return max(remaining, time.Second), nil
+}
+
+func (s *OTPRedisStore) attemptsKey(scope OTPScope, id string) string {
+	return "otp:attempts:" + string(scope) + ":" + id
+}
+
+func (s *OTPRedisStore) lockKey(scope OTPScope, id string) string {
+	return "otp:lock:" + string(scope) + ":" + id
+}
+
+func (s *OTPRedisStore) IncrFailedAttempts(ctx context.Context, scope OTPScope, id string, window time.Duration) (int64, error) {
+	key := s.attemptsKey(scope, id)
+
+	pipe := s.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s *OTPRedisStore) ResetFailedAttempts(ctx context.Context, scope OTPScope, id string) error {
+	return s.rdb.Del(ctx, s.attemptsKey(scope, id)).Err()
+}
+
+func (s *OTPRedisStore) Lock(ctx context.Context, scope OTPScope, id string, ttl time.Duration) error {
+	pipe := s.rdb.TxPipeline()
+	pipe.Set(ctx, s.lockKey(scope, id), 1, ttl)
+	pipe.Del(ctx, s.attemptsKey(scope, id))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *OTPRedisStore) LockedFor(ctx context.Context, scope OTPScope, id string) (time.Duration, error) {
+	ttl, err := s.rdb.PTTL(ctx, s.lockKey(scope, id)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// negative values mean the key is missing or has no expiry
+	return max(ttl, 0), nil
+}
@@ -0,0 +1,50 @@
+package redisStore
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+type UploadIntentRedisStore struct {
+	rdb *redis.Client
+}
+
+func NewUploadIntentRedisStore(rdb *redis.Client) *UploadIntentRedisStore {
+	return &UploadIntentRedisStore{rdb: rdb}
+}
+
+func (s *UploadIntentRedisStore) key(id string) string {
+	return "upload:intent:" + id
+}
+
+func (s *UploadIntentRedisStore) SaveUploadIntent(ctx context.Context, intent *domain.UploadIntent, ttl time.Duration) error {
+	data, err := json.Marshal(intent)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, s.key(intent.ID), data, ttl).Err()
+}
+
+func (s *UploadIntentRedisStore) GetUploadIntent(ctx context.Context, id string) (*domain.UploadIntent, error) {
+	data, err := s.rdb.Get(ctx, s.key(id)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var intent domain.UploadIntent
+	if err := json.Unmarshal(data, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (s *UploadIntentRedisStore) DeleteUploadIntent(ctx context.Context, id string) error {
+	return s.rdb.Del(ctx, s.key(id)).Err()
+}
@@ -52,8 +52,10 @@ func (h *HMACHasher) Hash(value string) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
+// Compare runs in constant time so the response time does not reveal how much of the
+// hash matched.
 func (h *HMACHasher) Compare(value, hash string) bool {
-	return h.Hash(value) == hash
+	return hmac.Equal([]byte(h.Hash(value)), []byte(hash))
 }
 
 type CodeHasher interface {
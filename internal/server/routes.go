@@ -2,6 +2,9 @@ package server
 
 import (
 	"net/http"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/localstore"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
 )
 
 func (s *Server) setupRoutes() {
@@ -9,9 +12,10 @@ func (s *Server) setupRoutes() {
 	s.mux.HandleFunc("/health", healthCheck)
 
 	// auth
-	s.mux.HandleFunc("/api/v1/register", s.authHandler.Register)
-	s.mux.HandleFunc("/api/v1/verify", s.authHandler.VerifyUser)
-	s.mux.HandleFunc("/api/v1/login", s.authHandler.Login)
+	s.mux.Handle("/api/v1/register", s.rateLimiter.Wrap(middleware.RateLimitRegister, middleware.ByIP, http.HandlerFunc(s.authHandler.Register)))
+	s.mux.Handle("/api/v1/verify", s.rateLimiter.Wrap(middleware.RateLimitVerify, middleware.ByIP, http.HandlerFunc(s.authHandler.VerifyUser)))
+	s.mux.Handle("/api/v1/verify/resend", s.rateLimiter.Wrap(middleware.RateLimitVerify, middleware.ByIP, http.HandlerFunc(s.authHandler.ResendCode)))
+	s.mux.Handle("/api/v1/login", s.rateLimiter.Wrap(middleware.RateLimitLogin, middleware.ByIP, http.HandlerFunc(s.authHandler.Login)))
 	s.mux.Handle("/api/v1/logout", s.authMiddleware.WrapAccess(http.HandlerFunc(s.authHandler.Logout)))
 	s.mux.Handle("/api/v1/refresh", s.authMiddleware.WrapAccess(http.HandlerFunc(s.authHandler.Refresh)))
 
@@ -32,10 +36,58 @@ func (s *Server) setupRoutes() {
 	s.mux.Handle("/api/v1/chat/conversations", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetConversations)))
 	s.mux.Handle("/api/v1/chat/dm", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.StartDM)))
 	s.mux.Handle("/api/v1/chat/group", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.CreateGroup)))
+	s.mux.Handle("/api/v1/chat/channel", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.CreateChannel)))
+	s.mux.Handle("/api/v1/chat/channel/resolve", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetChannel)))
+	s.mux.Handle("/api/v1/chat/channel/subscribe", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.Subscribe)))
+	s.mux.Handle("/api/v1/chat/members", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetMembers)))
+	s.mux.Handle("/api/v1/chat/members/add", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.AddMembers)))
+	s.mux.Handle("/api/v1/chat/members/remove", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.RemoveMember)))
+	s.mux.Handle("/api/v1/chat/members/role", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.SetMemberRole)))
+	s.mux.Handle("/api/v1/chat/leave", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.LeaveChat)))
+	s.mux.Handle("/api/v1/chat/owner/transfer", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.TransferOwnership)))
+	s.mux.Handle("/api/v1/chat/invites", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetInviteLinks)))
+	s.mux.Handle("/api/v1/chat/invites/create", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.CreateInviteLink)))
+	s.mux.Handle("/api/v1/chat/invites/revoke", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.RevokeInviteLink)))
+	s.mux.Handle("/api/v1/chat/join", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.JoinByInvite)))
+	s.mux.Handle("/api/v1/chat/join/request", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.RequestToJoin)))
+	s.mux.Handle("/api/v1/chat/join-requests", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetJoinRequests)))
+	s.mux.Handle("/api/v1/chat/join-requests/approve", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.ApproveJoinRequests)))
+	s.mux.Handle("/api/v1/chat/join-requests/decline", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.DeclineJoinRequests)))
+	s.mux.Handle("/api/v1/chat/moderation/ban", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.BanMember)))
+	s.mux.Handle("/api/v1/chat/moderation/unban", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.UnbanMember)))
+	s.mux.Handle("/api/v1/chat/moderation/restrict", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.RestrictMember)))
+	s.mux.Handle("/api/v1/chat/moderation/unrestrict", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.UnrestrictMember)))
+	s.mux.Handle("/api/v1/chat/moderation/mute", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.MuteMember)))
+	s.mux.Handle("/api/v1/chat/moderation/unmute", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.UnmuteMember)))
+	s.mux.Handle("/api/v1/chat/moderation/log", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetModerationLog)))
+	s.mux.Handle("/api/v1/chat/messages", s.authMiddleware.WrapAccess(s.rateLimiter.Wrap(middleware.RateLimitSendMessage, middleware.ByUser, http.HandlerFunc(s.chatHandler.SendMessage))))
 	s.mux.Handle("/api/v1/chat/messages/history", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetMessages)))
+	s.mux.Handle("/api/v1/chat/messages/edit", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.EditMessage)))
+	s.mux.Handle("/api/v1/chat/messages/delete", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.DeleteMessage)))
+	s.mux.Handle("/api/v1/chat/messages/forward", s.authMiddleware.WrapAccess(s.rateLimiter.Wrap(middleware.RateLimitSendMessage, middleware.ByUser, http.HandlerFunc(s.chatHandler.ForwardMessages))))
+	s.mux.Handle("/api/v1/chat/messages/edits", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetMessageEdits)))
+	s.mux.Handle("/api/v1/chat/messages/read-by", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetReadBy)))
+	s.mux.Handle("/api/v1/chat/messages/reactions", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.GetReactions)))
+	s.mux.Handle("/api/v1/chat/messages/reactions/add", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.AddReaction)))
+	s.mux.Handle("/api/v1/chat/messages/reactions/remove", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.RemoveReaction)))
+	s.mux.Handle("/api/v1/chat/read", s.authMiddleware.WrapAccess(http.HandlerFunc(s.chatHandler.MarkRead)))
+
+	// realtime
+	s.mux.Handle("/api/v1/ws", s.authMiddleware.WrapWebSocket(http.HandlerFunc(s.wsHandler.ServeWS)))
 
 	// media 
-	s.mux.Handle("/api/v1/media/upload", s.authMiddleware.WrapAccess(http.HandlerFunc(s.mediaHandler.UploadMedia)))
+	s.mux.Handle("/api/v1/media/upload", s.authMiddleware.WrapAccess(s.rateLimiter.Wrap(middleware.RateLimitUpload, middleware.ByUser, http.HandlerFunc(s.mediaHandler.UploadMedia))))
+	s.mux.Handle("/api/v1/media/uploads", s.authMiddleware.WrapAccess(s.rateLimiter.Wrap(middleware.RateLimitUpload, middleware.ByUser, http.HandlerFunc(s.mediaHandler.CreateUpload))))
+	s.mux.Handle("/api/v1/media/uploads/complete", s.authMiddleware.WrapAccess(http.HandlerFunc(s.mediaHandler.CompleteUpload)))
+	s.mux.Handle("/api/v1/media/resumable", s.authMiddleware.WrapAccess(s.rateLimiter.Wrap(middleware.RateLimitUpload, middleware.ByUser, http.HandlerFunc(s.mediaHandler.CreateResumableUpload))))
+	s.mux.Handle("/api/v1/media/resumable/chunk", s.authMiddleware.WrapAccess(http.HandlerFunc(s.mediaHandler.AppendChunk)))
+	s.mux.Handle("/api/v1/media/resumable/status", s.authMiddleware.WrapAccess(http.HandlerFunc(s.mediaHandler.GetResumableUpload)))
+	s.mux.Handle("/api/v1/media/resumable/abort", s.authMiddleware.WrapAccess(http.HandlerFunc(s.mediaHandler.AbortResumableUpload)))
+
+	// local object storage, authorized by the URL signature instead of a session
+	if s.storageHandler != nil {
+		s.mux.HandleFunc(localstore.URLPrefix, s.storageHandler.ServeObject)
+	}
 }
 
 func healthCheck(w http.ResponseWriter, r *http.Request) {
@@ -12,7 +12,9 @@ import (
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/media"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/session"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/storage"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/user"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/ws"
 	"github.com/rs/zerolog"
 )
 
@@ -20,32 +22,38 @@ type Server struct {
 	mux            *http.ServeMux
 	http           *http.Server
 	authMiddleware *middleware.AuthMiddleware
+	rateLimiter    *middleware.RateLimiter
 	authHandler    *auth.AuthHandler
 	sessionHandler *session.SessionHandler
 	userHandler    *user.UserHandler
 	mediaHandler   *media.MediaHandler
 	chatHandler    *chat.ChatHandler
+	storageHandler *storage.StorageHandler // nil when objects live in MinIO
+	wsHandler      *ws.WSHandler
 	logger         zerolog.Logger
 }
 
-func NewServer(cfg config.Server, authMiddleware *middleware.AuthMiddleware, logger zerolog.Logger,
-	authHandler *auth.AuthHandler, sessionHandler *session.SessionHandler, userHandler *user.UserHandler, mediaHandler *media.MediaHandler, chatHandler *chat.ChatHandler) *Server {
+func NewServer(cfg config.Server, proxies middleware.TrustedProxies, authMiddleware *middleware.AuthMiddleware, rateLimiter *middleware.RateLimiter, logger zerolog.Logger,
+	authHandler *auth.AuthHandler, sessionHandler *session.SessionHandler, userHandler *user.UserHandler, mediaHandler *media.MediaHandler, chatHandler *chat.ChatHandler, storageHandler *storage.StorageHandler, wsHandler *ws.WSHandler) *Server {
 	mux := http.NewServeMux()
 
 	s := &Server{
 		mux:            mux,
 		authMiddleware: authMiddleware,
+		rateLimiter:    rateLimiter,
 		authHandler:    authHandler,
 		sessionHandler: sessionHandler,
 		userHandler:    userHandler,
 		logger:         logger,
 		mediaHandler:   mediaHandler,
 		chatHandler:    chatHandler,
+		storageHandler: storageHandler,
+		wsHandler:      wsHandler,
 	}
 
 	var handler http.Handler = mux
-	handler = middleware.MetaMiddleware(handler)
 	handler = middleware.Logging(logger, handler)
+	handler = middleware.MetaMiddleware(proxies, handler)
 	handler = middleware.CORS(handler)
 
 	s.http = &http.Server{
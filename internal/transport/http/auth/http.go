@@ -40,6 +40,35 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *AuthHandler) ResendCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req authUsecase.ResendCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request: Invalid Json", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authUsecase.ResendCode(r.Context(), req); err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(map[string]any{
+		"message": "If the account is waiting for verification, a new code has been sent",
+		"success": true,
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to encode response")
+		http.Error(w, "INTERNAL SERVER ERROR", http.StatusInternalServerError)
+		return
+	}
+}
+
 func (h *AuthHandler) VerifyUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method now allowed", http.StatusMethodNotAllowed)
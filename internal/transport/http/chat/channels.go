@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
+	chatUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/chat"
+)
+
+func (h *ChatHandler) CreateChannel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.CreateChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.CreateChannel(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ChatHandler) GetChannel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := middleware.UserIDFromContext(r.Context()); !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := h.usecase.GetChannel(r.Context(), r.URL.Query().Get("username"))
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ChatHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.SubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.Subscribe(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
 	chatUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/chat"
@@ -107,13 +108,24 @@ func (h *ChatHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit == 0 {
-		limit = 20
+	q := r.URL.Query()
+	req := chatUsecase.GetMessagesRequest{ConversationID: convID}
+	for name, dst := range map[string]*uint64{"before_id": &req.BeforeID, "after_id": &req.AfterID, "around_id": &req.AroundID} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid "+name, http.StatusBadRequest)
+			return
+		}
+		*dst = id
 	}
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	req.Limit, _ = strconv.Atoi(q.Get("limit"))
+	req.Variant = domain.MediaVariant(q.Get("variant"))
 
-	messages, err := h.usecase.GetMessages(r.Context(), userID, convID, limit, offset)
+	messages, err := h.usecase.GetMessages(r.Context(), userID, req)
 	if err != nil {
 		apperr.WriteError(w, err, &h.logger)
 		return
@@ -122,3 +134,282 @@ func (h *ChatHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(messages)
 }
+
+func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.SendMessage(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ChatHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.EditMessage(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ChatHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	msgID, err := strconv.ParseUint(q.Get("message_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+	forEveryone, _ := strconv.ParseBool(q.Get("for_everyone"))
+
+	req := chatUsecase.DeleteMessageRequest{MessageID: msgID, ForEveryone: forEveryone}
+	if err := h.usecase.DeleteMessage(r.Context(), userID, req); err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatHandler) GetMessageEdits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	msgID, err := strconv.ParseUint(r.URL.Query().Get("message_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	edits, err := h.usecase.GetMessageEdits(r.Context(), userID, msgID)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(edits)
+}
+
+func (h *ChatHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.MarkReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usecase.MarkRead(r.Context(), userID, req); err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatHandler) GetReadBy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	msgID, err := strconv.ParseUint(r.URL.Query().Get("message_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	receipts, err := h.usecase.GetReadBy(r.Context(), userID, msgID)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipts)
+}
+
+func (h *ChatHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usecase.AddReaction(r.Context(), userID, req); err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	msgID, err := strconv.ParseUint(q.Get("message_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	req := chatUsecase.ReactionRequest{MessageID: msgID, Emoji: q.Get("emoji")}
+	if err := h.usecase.RemoveReaction(r.Context(), userID, req); err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatHandler) GetReactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	msgID, err := strconv.ParseUint(r.URL.Query().Get("message_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	reactions, err := h.usecase.GetReactions(r.Context(), userID, msgID)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reactions)
+}
+
+func (h *ChatHandler) ForwardMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.ForwardMessagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.ForwardMessages(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,122 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
+	chatUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/chat"
+)
+
+func (h *ChatHandler) GetInviteLinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	convID, err := strconv.ParseUint(r.URL.Query().Get("conversation_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid conversation ID", http.StatusBadRequest)
+		return
+	}
+
+	links, err := h.usecase.GetInviteLinks(r.Context(), userID, convID)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+func (h *ChatHandler) CreateInviteLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.CreateInviteLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.CreateInviteLink(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ChatHandler) RevokeInviteLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.RevokeInviteLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usecase.RevokeInviteLink(r.Context(), userID, req); err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatHandler) JoinByInvite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.JoinByInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.JoinByInvite(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,101 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
+	chatUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/chat"
+)
+
+func (h *ChatHandler) BanMember(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.usecase.BanMember)
+}
+
+func (h *ChatHandler) UnbanMember(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.usecase.UnbanMember)
+}
+
+func (h *ChatHandler) RestrictMember(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.usecase.RestrictMember)
+}
+
+func (h *ChatHandler) UnrestrictMember(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.usecase.UnrestrictMember)
+}
+
+func (h *ChatHandler) MuteMember(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.usecase.MuteMember)
+}
+
+func (h *ChatHandler) UnmuteMember(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.usecase.UnmuteMember)
+}
+
+// moderate serves the moderation actions, which share their request and response.
+func (h *ChatHandler) moderate(w http.ResponseWriter, r *http.Request, action func(context.Context, uint64, chatUsecase.ModerationRequest) error) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req chatUsecase.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	if err := action(r.Context(), userID, req); err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatHandler) GetModerationLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	convID, err := strconv.ParseUint(q.Get("conversation_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid conversation ID", http.StatusBadRequest)
+		return
+	}
+
+	req := chatUsecase.GetModerationLogRequest{ConversationID: convID}
+	if v := q.Get("before_id"); v != "" {
+		if req.BeforeID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, "Invalid before_id", http.StatusBadRequest)
+			return
+		}
+	}
+	req.Limit, _ = strconv.Atoi(q.Get("limit"))
+
+	resp, err := h.usecase.GetModerationLog(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
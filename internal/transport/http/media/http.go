@@ -3,8 +3,12 @@ package media
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
+	usecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/media"
 )
 
 
@@ -14,6 +18,12 @@ func (h *MediaHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    userID, ok := middleware.UserIDFromContext(r.Context())
+    if !ok {
+        http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+        return
+    }
+
     // Parse the multipart form with a maximum memory of 5MB
     err := r.ParseMultipartForm(5 << 20)
     if err != nil {
@@ -33,7 +43,12 @@ func (h *MediaHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
         contentType = "application/octet-stream"
     }
 
-    resp, err := h.usecase.UploadMedia(r.Context(), file, header.Filename, header.Size, contentType)
+    purpose := domain.MediaPurpose(r.FormValue("purpose"))
+    if purpose == "" {
+        purpose = domain.MediaPurposeAttachment
+    }
+
+    resp, err := h.usecase.UploadMedia(r.Context(), userID, purpose, file, header.Filename, header.Size, contentType)
     if err != nil {
         apperr.WriteError(w, err, &h.logger)
         return
@@ -50,6 +65,12 @@ func (h *MediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
 	var req struct {
 		ObjectName string `json:"object_name"`
 	}
@@ -59,7 +80,7 @@ func (h *MediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.usecase.DeleteMedia(r.Context(), req.ObjectName); err != nil {
+	if err := h.usecase.DeleteMedia(r.Context(), userID, req.ObjectName); err != nil {
 		apperr.WriteError(w, err, &h.logger)
 		return
 	}
@@ -71,4 +92,166 @@ func (h *MediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-}
\ No newline at end of file
+}
+
+func (h *MediaHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req usecase.CreateUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.CreateUpload(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *MediaHandler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req usecase.CompleteUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.CompleteUpload(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *MediaHandler) CreateResumableUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	var req usecase.CreateResumableUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.usecase.CreateResumableUpload(r.Context(), userID, req)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AppendChunk takes the raw chunk as the request body, e.g.
+// PUT /api/v1/media/resumable/chunk?upload_id=...&offset=8388608
+func (h *MediaHandler) AppendChunk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "BAD REQUEST", http.StatusBadRequest)
+		return
+	}
+	if r.ContentLength < 0 {
+		http.Error(w, "Content-Length is required", http.StatusLengthRequired)
+		return
+	}
+
+	resp, err := h.usecase.AppendChunk(r.Context(), userID, r.URL.Query().Get("upload_id"), offset, r.Body, r.ContentLength)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *MediaHandler) GetResumableUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := h.usecase.GetResumableUpload(r.Context(), userID, r.URL.Query().Get("upload_id"))
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *MediaHandler) AbortResumableUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.usecase.AbortResumableUpload(r.Context(), userID, r.URL.Query().Get("upload_id")); err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
@@ -46,11 +49,11 @@ func MetaFromContext(ctx context.Context) (RequestMeta, bool) {
 	return RequestMeta{IP: ip, UserAgent: ua, Device: device}, true
 }
 
-func MetaMiddleware(next http.Handler) http.Handler {
+func MetaMiddleware(proxies TrustedProxies, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ua := r.UserAgent()
 		meta := RequestMeta{
-			IP:        clientIP(r),
+			IP:        proxies.ClientIP(r),
 			UserAgent: ua,
 			Device:    deviceLabel(ua),
 		}
@@ -74,31 +77,52 @@ func (m *AuthMiddleware) WrapAccess(next http.Handler) http.Handler {
 				access = c.Value
 			}
 		}
-		if access == "" {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
+		m.serveAccess(w, r, access, next)
+	})
+}
 
-		sess, err := m.Sessions.GetByAccessToken(r.Context(), access)
-		if err != nil || sess == nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
+// WrapWebSocket works like WrapAccess but also accepts the access token as the
+// "access_token" query parameter, because browsers cannot set headers on a WebSocket handshake.
+func (m *AuthMiddleware) WrapWebSocket(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access := extractBearer(r.Header.Get("Authorization"))
+		if access == "" {
+			if c, err := r.Cookie("access_token"); err == nil {
+				access = c.Value
+			}
 		}
-		if sess.RevokedAt != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
+		if access == "" {
+			access = r.URL.Query().Get("access_token")
 		}
+		m.serveAccess(w, r, access, next)
+	})
+}
 
-		now := time.Now()
-		if now.After(sess.AccessTokenExp) {
-			http.Error(w, "access token expired", http.StatusUnauthorized)
-			return
-		}
+func (m *AuthMiddleware) serveAccess(w http.ResponseWriter, r *http.Request, access string, next http.Handler) {
+	if access == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-		ctx := context.WithValue(r.Context(), CtxUserID, sess.UserID)
-		ctx = context.WithValue(ctx, CtxSessionID, sess.ID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	sess, err := m.Sessions.GetByAccessToken(r.Context(), access)
+	if err != nil || sess == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if sess.RevokedAt != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	if now.After(sess.AccessTokenExp) {
+		http.Error(w, "access token expired", http.StatusUnauthorized)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), CtxUserID, sess.UserID)
+	ctx = context.WithValue(ctx, CtxSessionID, sess.ID)
+	next.ServeHTTP(w, r.WithContext(ctx))
 }
 
 func (m *AuthMiddleware) WrapRefresh(next http.Handler) http.Handler {
@@ -159,6 +183,16 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Hijack lets WebSocket upgrades take over the connection through the logging wrapper.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	rw.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
 func Logging(l zerolog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -179,7 +213,7 @@ func Logging(l zerolog.Logger, next http.Handler) http.Handler {
 		meta, ok := MetaFromContext(r.Context())
 		if !ok {
 			ua := r.UserAgent()
-			meta = RequestMeta{IP: clientIP(r), UserAgent: ua, Device: deviceLabel(ua)}
+			meta = RequestMeta{IP: remoteIP(r), UserAgent: ua, Device: deviceLabel(ua)}
 		}
 
 		evt.
@@ -224,18 +258,84 @@ func extractBearer(authHeader string) string {
 	return strings.TrimSpace(parts[1])
 }
 
-func clientIP(r *http.Request) string {
-	// X-Forwarded-For: "client, proxy1, proxy2"
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		first := strings.TrimSpace(strings.Split(xff, ",")[0])
-		if first != "" {
-			return first
+// TrustedProxies are the peers allowed to report the client address in X-Forwarded-For
+// and X-Real-IP. Clients can send those headers themselves, so they are ignored unless
+// the request comes from one of these.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses proxy addresses and CIDR ranges.
+func ParseTrustedProxies(list []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
 		}
+		proxies = append(proxies, ipNet)
 	}
-	if xrip := strings.TrimSpace(r.Header.Get("X-Real-IP")); xrip != "" {
-		return xrip
+	return proxies, nil
+}
+
+func (t TrustedProxies) trusted(ip net.IP) bool {
+	for _, ipNet := range t {
+		if ipNet.Contains(ip) {
+			return true
+		}
 	}
+	return false
+}
 
+// ClientIP returns the address of the client. Behind trusted proxies it is the right-most
+// X-Forwarded-For address that is not a trusted proxy, anything left of it was written
+// by the client. Otherwise it is the peer address.
+func (t TrustedProxies) ClientIP(r *http.Request) string {
+	peer := remoteIP(r)
+	ip := net.ParseIP(peer)
+	if ip == nil || !t.trusted(ip) {
+		return peer
+	}
+
+	// X-Forwarded-For: "client, proxy1, proxy2", possibly split over several headers
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	if len(hops) == 0 {
+		if xrip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); xrip != nil {
+			return xrip.String()
+		}
+		return peer
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		client = hop.String()
+		if !t.trusted(hop) {
+			break
+		}
+	}
+	return client
+}
+
+// remoteIP is the address of the peer the request came from.
+func remoteIP(r *http.Request) string {
 	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
 	if err == nil && host != "" {
 		return host
@@ -249,6 +349,12 @@ func UserIDFromContext(ctx context.Context) (uint64, bool) {
 	return id, ok
 }
 
+func SessionIDFromContext(ctx context.Context) (uint64, bool) {
+	v := ctx.Value(CtxSessionID)
+	id, ok := v.(uint64)
+	return id, ok
+}
+
 func parseClient(ua string) string {
 	u := strings.ToLower(ua)
 	switch {
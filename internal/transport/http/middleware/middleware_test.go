@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:4000", nil, "", "203.0.113.7"},
+		{"direct client spoofing forwarded for", "203.0.113.7:4000", []string{"198.51.100.1"}, "", "203.0.113.7"},
+		{"direct client spoofing real ip", "203.0.113.7:4000", nil, "198.51.100.1", "203.0.113.7"},
+		{"behind a proxy", "10.0.0.2:4000", []string{"203.0.113.7"}, "", "203.0.113.7"},
+		{"client prepends a fake hop", "10.0.0.2:4000", []string{"198.51.100.1, 203.0.113.7"}, "", "203.0.113.7"},
+		{"chain of proxies", "10.0.0.2:4000", []string{"203.0.113.7, 192.0.2.1", "10.1.1.1"}, "", "203.0.113.7"},
+		{"real ip from a proxy", "192.0.2.1:4000", nil, "203.0.113.7", "203.0.113.7"},
+		{"garbage hop", "10.0.0.2:4000", []string{"203.0.113.7, not-an-ip, 10.1.1.1"}, "", "10.1.1.1"},
+		{"only proxies", "10.0.0.2:4000", []string{"10.1.1.1"}, "", "10.1.1.1"},
+		{"ipv6 client", "[2001:db8::1]:4000", []string{"198.51.100.1"}, "", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := proxies.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, bad := range []string{"", "10.0.0.0/33", "proxy.local"} {
+		if _, err := ParseTrustedProxies([]string{bad}); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded, want an error", bad)
+		}
+	}
+}
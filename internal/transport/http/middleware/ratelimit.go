@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/config"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/ratelimit"
+	"github.com/rs/zerolog"
+)
+
+// Route groups that share a rate limit.
+const (
+	RateLimitLogin       = "login"
+	RateLimitRegister    = "register"
+	RateLimitVerify      = "verify"
+	RateLimitSendMessage = "send_message"
+	RateLimitUpload      = "upload"
+)
+
+var defaultRateLimits = map[string]ratelimit.Rule{
+	RateLimitLogin:       {Limit: 10, Window: time.Minute},
+	RateLimitRegister:    {Limit: 5, Window: 10 * time.Minute},
+	RateLimitVerify:      {Limit: 10, Window: 10 * time.Minute},
+	RateLimitSendMessage: {Limit: 60, Window: time.Minute},
+	RateLimitUpload:      {Limit: 30, Window: time.Minute},
+}
+
+// KeyFunc returns who a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+// ByIP counts requests per client IP, as resolved by MetaMiddleware. Forwarding headers
+// only count when they come from a trusted proxy, so clients cannot pick their bucket.
+func ByIP(r *http.Request) string {
+	if meta, ok := MetaFromContext(r.Context()); ok && meta.IP != "" {
+		return "ip:" + meta.IP
+	}
+	return "ip:" + remoteIP(r)
+}
+
+// ByUser counts requests per authenticated user, and per IP when there is none. It must
+// run inside the auth middleware.
+func ByUser(r *http.Request) string {
+	if userID, ok := UserIDFromContext(r.Context()); ok {
+		return "user:" + strconv.FormatUint(userID, 10)
+	}
+	return ByIP(r)
+}
+
+type RateLimiter struct {
+	limiter ratelimit.Limiter
+	rules   map[string]ratelimit.Rule
+	logger  zerolog.Logger
+}
+
+func NewRateLimiter(limiter ratelimit.Limiter, cfg config.RateLimitConfig, logger zerolog.Logger) *RateLimiter {
+	rules := make(map[string]ratelimit.Rule, len(defaultRateLimits))
+	for group, rule := range defaultRateLimits {
+		rules[group] = rule
+	}
+	for group, rule := range cfg.Rules {
+		if rule.Limit > 0 && rule.Window >= time.Millisecond {
+			rules[group] = ratelimit.Rule{Limit: rule.Limit, Window: rule.Window}
+		}
+	}
+
+	return &RateLimiter{limiter: limiter, rules: rules, logger: logger}
+}
+
+// Wrap limits next with the rule of group, counting requests by key. Every response
+// carries the X-RateLimit-* headers, rejected ones also Retry-After.
+func (m *RateLimiter) Wrap(group string, key KeyFunc, next http.Handler) http.Handler {
+	rule, ok := m.rules[group]
+	if !ok {
+		panic(fmt.Sprintf("rate limit group %q has no rule", group))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		res, err := m.limiter.Allow(r.Context(), group+":"+key(r), rule)
+		if err != nil {
+			// limiting is best effort, never fail the request because of it
+			m.logger.Error().Err(err).Str("group", group).Msg("rate limiter failed")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("X-RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+
+		if !res.Allowed {
+			retryAfter := seconds(res.RetryAfter)
+			h.Set("Retry-After", strconv.Itoa(retryAfter))
+
+			err := apperr.New(apperr.CodeRateLimited, http.StatusTooManyRequests, "too many requests, try again later")
+			err.Fields = map[string]string{"retry_after": strconv.Itoa(retryAfter)}
+			apperr.WriteError(w, err, &m.logger)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// seconds rounds up so clients never retry too early.
+func seconds(d time.Duration) int {
+	return max(int(math.Ceil(d.Seconds())), 1)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestByIPIgnoresSpoofedHeaders(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var key string
+	handler := MetaMiddleware(proxies, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = ByIP(r)
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct", "203.0.113.7:4000", "", "", "ip:203.0.113.7"},
+		{"spoofed forwarded for", "203.0.113.7:4000", "198.51.100.1", "", "ip:203.0.113.7"},
+		{"another spoofed forwarded for", "203.0.113.7:4000", "198.51.100.2", "", "ip:203.0.113.7"},
+		{"spoofed real ip", "203.0.113.7:4000", "", "198.51.100.1", "ip:203.0.113.7"},
+		{"spoofed hop behind a proxy", "10.0.0.2:4000", "198.51.100.1, 203.0.113.7", "", "ip:203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			if key != tt.want {
+				t.Errorf("ByIP() = %q, want %q", key, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/localstore"
+	"github.com/rs/zerolog"
+)
+
+// maxObjectSize matches the S3 limit for a single PUT.
+const maxObjectSize = 5 << 30
+
+// StorageHandler serves the signed URLs of the local storage drivers, playing the part
+// MinIO plays for presigned URLs.
+type StorageHandler struct {
+	storage *localstore.Storage
+	logger  zerolog.Logger
+}
+
+func NewStorageHandler(storage *localstore.Storage, logger zerolog.Logger) *StorageHandler {
+	return &StorageHandler{
+		storage: storage,
+		logger:  logger,
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/localstore"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/minio"
+)
+
+// ServeObject downloads (GET, HEAD) or uploads (PUT) the object a signed URL points at.
+// The signature is the only authorization, exactly like a presigned S3 URL.
+func (h *StorageHandler) ServeObject(w http.ResponseWriter, r *http.Request) {
+	method := r.Method
+	switch method {
+	case http.MethodGet, http.MethodPut:
+	case http.MethodHead:
+		method = http.MethodGet
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, localstore.URLPrefix)
+	q := r.URL.Query()
+	if err := h.storage.Signer().Verify(method, key, q.Get("expires"), q.Get("signature")); err != nil {
+		http.Error(w, "FORBIDDEN", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		h.putObject(w, r, key)
+		return
+	}
+	h.getObject(w, r, key)
+}
+
+func (h *StorageHandler) getObject(w http.ResponseWriter, r *http.Request, key string) {
+	info, err := h.storage.Stat(r.Context(), key)
+	if errors.Is(err, minio.ErrObjectNotFound) {
+		http.Error(w, "NOT FOUND", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Str("key", key).Msg("failed to stat object")
+		http.Error(w, "INTERNAL SERVER ERROR", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.Header().Set("ETag", `"`+info.ETag+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// objects are served from the API's own origin: nothing in them may run there, and
+	// only media is shown inline, everything else is downloaded
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	if !inline(info.ContentType) {
+		w.Header().Set("Content-Disposition", "attachment")
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	obj, err := h.storage.Get(r.Context(), key)
+	if err != nil {
+		h.logger.Error().Err(err).Str("key", key).Msg("failed to open object")
+		http.Error(w, "INTERNAL SERVER ERROR", http.StatusInternalServerError)
+		return
+	}
+	defer obj.Close()
+
+	if _, err := io.Copy(w, obj); err != nil {
+		h.logger.Warn().Err(err).Str("key", key).Msg("failed to send object")
+	}
+}
+
+func (h *StorageHandler) putObject(w http.ResponseWriter, r *http.Request, key string) {
+	if r.ContentLength < 0 {
+		http.Error(w, "LENGTH REQUIRED", http.StatusLengthRequired)
+		return
+	}
+	if r.ContentLength > maxObjectSize {
+		http.Error(w, "REQUEST ENTITY TOO LARGE", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	etag, err := h.storage.Put(r.Context(), key, r.Body, r.ContentLength, contentType)
+	if err != nil {
+		h.logger.Error().Err(err).Str("key", key).Msg("failed to store object")
+		http.Error(w, "INTERNAL SERVER ERROR", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+etag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// inline reports whether an object of the type may be displayed by the browser.
+func inline(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "image/") && !strings.HasSuffix(mediaType, "+xml") ||
+		strings.HasPrefix(mediaType, "video/")
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/localstore"
+	"github.com/rs/zerolog"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *localstore.Storage) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	store := localstore.NewMemory(localstore.NewSigner(srv.URL, []byte("test key")))
+	mux.Handle(localstore.URLPrefix, http.HandlerFunc(NewStorageHandler(store, zerolog.Nop()).ServeObject))
+	return srv, store
+}
+
+func do(t *testing.T, method, url, contentType, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServeObjectRoundTrip(t *testing.T) {
+	_, store := newTestServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		key         string
+		contentType string
+		disposition string
+	}{
+		{"image shown inline", "media/photo.png", "image/png", ""},
+		{"video shown inline", "media/clip.mp4", "video/mp4", ""},
+		{"svg downloaded", "media/drawing.svg", "image/svg+xml", "attachment"},
+		{"text downloaded", "media/notes.txt", "text/plain; charset=utf-8", "attachment"},
+		{"unknown downloaded", "media/blob", "application/octet-stream", "attachment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := "content of " + tt.key
+
+			putURL, err := store.PresignPut(ctx, tt.key, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp := do(t, http.MethodPut, putURL, tt.contentType, body); resp.StatusCode != http.StatusOK {
+				t.Fatalf("PUT status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+
+			getURL, err := store.PresignGet(ctx, tt.key, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp := do(t, http.MethodGet, getURL, "", "")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("GET status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			got, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != body {
+				t.Errorf("body = %q, want %q", got, body)
+			}
+
+			for header, want := range map[string]string{
+				"Content-Type":            tt.contentType,
+				"Content-Security-Policy": "sandbox",
+				"X-Content-Type-Options":  "nosniff",
+				"Content-Disposition":     tt.disposition,
+			} {
+				if v := resp.Header.Get(header); v != want {
+					t.Errorf("%s = %q, want %q", header, v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServeObjectRejectsBadURLs(t *testing.T) {
+	srv, store := newTestServer(t)
+	ctx := context.Background()
+	signer := store.Signer()
+
+	putURL, err := store.PresignPut(ctx, "media/file", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := do(t, http.MethodPut, putURL, "text/plain", "secret"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	getURL, err := store.PresignGet(ctx, "media/file", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		want   int
+	}{
+		{"upload URL used to download", http.MethodGet, putURL, http.StatusForbidden},
+		{"download URL used to upload", http.MethodPut, getURL, http.StatusForbidden},
+		{"other key", http.MethodGet, strings.Replace(getURL, "media/file", "media/other", 1), http.StatusForbidden},
+		{"tampered signature", http.MethodGet, strings.Replace(getURL, "signature=", "signature=00", 1), http.StatusForbidden},
+		{"expired", http.MethodGet, signer.URL(http.MethodGet, "media/file", -time.Minute), http.StatusForbidden},
+		{"unsigned", http.MethodGet, srv.URL + localstore.URLPrefix + "media/file", http.StatusForbidden},
+		{"missing object", http.MethodGet, signer.URL(http.MethodGet, "media/missing", time.Minute), http.StatusNotFound},
+		{"head", http.MethodHead, getURL, http.StatusOK},
+		{"delete", http.MethodDelete, getURL, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := do(t, tt.method, tt.url, "", ""); resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
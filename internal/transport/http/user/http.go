@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
 	userUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/user"
@@ -22,7 +23,7 @@ func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.usecase.GetMe(r.Context(), userID)
+	user, err := h.usecase.GetMe(r.Context(), userID, domain.MediaVariant(r.URL.Query().Get("variant")))
 	if err != nil {
 		apperr.WriteError(w, err, &h.logger)
 		return
@@ -0,0 +1,120 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+const (
+	writeWait            = 10 * time.Second
+	pongWait             = 60 * time.Second
+	pingPeriod           = (pongWait * 9) / 10
+	sessionCheckInterval = 30 * time.Second
+	maxMessageSize       = 4096
+	sendBufferSize       = 256
+)
+
+// SessionChecker reports whether the session behind a connection is still valid.
+type SessionChecker func(ctx context.Context, sessionID uint64) (bool, error)
+
+// Client is a single WebSocket connection. A user has one client per connected session.
+type Client struct {
+	conn      *websocket.Conn
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	userID    uint64
+	sessionID uint64
+
+	// conversations delivered to this client, guarded by Hub.mu
+	convs map[uint64]struct{}
+}
+
+func newClient(conn *websocket.Conn, userID, sessionID uint64) *Client {
+	return &Client{
+		conn:      conn,
+		send:      make(chan []byte, sendBufferSize),
+		done:      make(chan struct{}),
+		userID:    userID,
+		sessionID: sessionID,
+		convs:     make(map[uint64]struct{}),
+	}
+}
+
+func (c *Client) close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+// readPump only keeps the connection alive: clients send messages through the HTTP API.
+func (c *Client) readPump(hub *Hub) {
+	defer hub.Unregister(c)
+
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump is the only goroutine writing to the connection. It also re-validates the
+// session periodically and closes the socket once the session is revoked or expired.
+func (c *Client) writePump(hub *Hub, isActive SessionChecker, logger zerolog.Logger) {
+	pingTicker := time.NewTicker(pingPeriod)
+	sessionTicker := time.NewTicker(sessionCheckInterval)
+	defer func() {
+		pingTicker.Stop()
+		sessionTicker.Stop()
+		_ = c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			c.writeClose(websocket.CloseGoingAway, "")
+			return
+
+		case msg := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				hub.Unregister(c)
+				return
+			}
+
+		case <-pingTicker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				hub.Unregister(c)
+				return
+			}
+
+		case <-sessionTicker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			active, err := isActive(ctx, c.sessionID)
+			cancel()
+			if err != nil {
+				logger.Error().Err(err).Uint64("session_id", c.sessionID).Msg("failed to check websocket session")
+				continue
+			}
+			if !active {
+				c.writeClose(websocket.ClosePolicyViolation, "session revoked")
+				hub.Unregister(c)
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) writeClose(code int, reason string) {
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+}
@@ -0,0 +1,33 @@
+package ws
+
+import (
+	"net/http"
+
+	chatUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/chat"
+	sessionUsecase "github.com/Jaxongir1006/Chat-X-v2/internal/usecase/session"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+type WSHandler struct {
+	hub      *Hub
+	chat     *chatUsecase.ChatUsecase
+	sessions *sessionUsecase.SessionUsecase
+	upgrader websocket.Upgrader
+	logger   zerolog.Logger
+}
+
+func NewWSHandler(hub *Hub, chat *chatUsecase.ChatUsecase, sessions *sessionUsecase.SessionUsecase, logger zerolog.Logger) *WSHandler {
+	return &WSHandler{
+		hub:      hub,
+		chat:     chat,
+		sessions: sessions,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// same policy as the CORS middleware: any origin, authentication is done by token
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		logger: logger,
+	}
+}
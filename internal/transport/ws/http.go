@@ -0,0 +1,54 @@
+package ws
+
+import (
+	"net/http"
+
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/transport/http/middleware"
+)
+
+func (h *WSHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+	sessionID, ok := middleware.SessionIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+
+	convIDs, err := h.chat.ConversationIDs(r.Context(), userID)
+	if err != nil {
+		apperr.WriteError(w, err, &h.logger)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already written an HTTP error response
+		h.logger.Warn().Err(err).Uint64("user_id", userID).Msg("failed to upgrade websocket connection")
+		return
+	}
+
+	client := newClient(conn, userID, sessionID)
+	if err := h.hub.Register(r.Context(), client, convIDs); err != nil {
+		h.logger.Error().Err(err).Uint64("user_id", userID).Msg("failed to register websocket client")
+		h.hub.Unregister(client)
+		_ = conn.Close()
+		return
+	}
+
+	h.logger.Info().Uint64("user_id", userID).Uint64("session_id", sessionID).Int("conversations", len(convIDs)).Msg("websocket connected")
+
+	go client.writePump(h.hub, h.sessions.IsSessionActive, h.logger)
+	client.readPump(h.hub)
+
+	h.logger.Info().Uint64("user_id", userID).Uint64("session_id", sessionID).Msg("websocket disconnected")
+}
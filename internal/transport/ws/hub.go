@@ -0,0 +1,262 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/eventbus"
+	"github.com/rs/zerolog"
+)
+
+// Hub keeps track of the live WebSocket connections on this instance and fans
+// chat events out to every connection whose user participates in the conversation.
+// It subscribes the event bus only to the users and conversations served locally.
+type Hub struct {
+	bus eventbus.Bus
+
+	// subMu serializes bus subscription changes so that a concurrent register and
+	// unregister cannot leave the bus unsubscribed from a channel still in use.
+	subMu sync.Mutex
+
+	mu      sync.RWMutex
+	byUser  map[uint64]map[*Client]struct{}
+	byConv  map[uint64]map[*Client]struct{}
+	clients map[*Client]struct{}
+
+	seen   *recentIDs
+	logger zerolog.Logger
+}
+
+func NewHub(bus eventbus.Bus, logger zerolog.Logger) *Hub {
+	return &Hub{
+		bus:     bus,
+		byUser:  make(map[uint64]map[*Client]struct{}),
+		byConv:  make(map[uint64]map[*Client]struct{}),
+		clients: make(map[*Client]struct{}),
+		seen:    newRecentIDs(4096),
+		logger:  logger,
+	}
+}
+
+// Register starts delivering events of the given conversations to the client.
+func (h *Hub) Register(ctx context.Context, c *Client, conversationIDs []uint64) error {
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+
+	var channels []string
+
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	if addToSet(h.byUser, c.userID, c) {
+		channels = append(channels, eventbus.UserChannel(c.userID))
+	}
+	for _, convID := range conversationIDs {
+		c.convs[convID] = struct{}{}
+		if addToSet(h.byConv, convID, c) {
+			channels = append(channels, eventbus.ConversationChannel(convID))
+		}
+	}
+	h.mu.Unlock()
+
+	return h.bus.Subscribe(ctx, channels...)
+}
+
+// Unregister stops delivering events to the client and closes it.
+func (h *Hub) Unregister(c *Client) {
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+
+	var channels []string
+
+	h.mu.Lock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		if removeFromSet(h.byUser, c.userID, c) {
+			channels = append(channels, eventbus.UserChannel(c.userID))
+		}
+		for convID := range c.convs {
+			if removeFromSet(h.byConv, convID, c) {
+				channels = append(channels, eventbus.ConversationChannel(convID))
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	c.close()
+
+	if err := h.bus.Unsubscribe(context.Background(), channels...); err != nil {
+		h.logger.Error().Err(err).Strs("channels", channels).Msg("failed to unsubscribe from event bus")
+	}
+}
+
+// Dispatch delivers an event received from the bus to the local connections of the
+// conversation's participants, or only to the listed users for user scoped events.
+// Membership events also subscribe or unsubscribe the affected users' connections.
+func (h *Hub) Dispatch(evt domain.Event) {
+	if evt.ID != "" && !h.seen.add(evt.ID) {
+		return
+	}
+
+	data, err := json.Marshal(evt)
+	if err != nil {
+		h.logger.Error().Err(err).Str("event", string(evt.Type)).Msg("failed to encode chat event")
+		return
+	}
+
+	if evt.Subscribes() {
+		h.subscribeUsers(evt.ConversationID, evt.UserIDs)
+	}
+
+	h.mu.RLock()
+	if evt.UserScoped() {
+		for _, userID := range evt.UserIDs {
+			for c := range h.byUser[userID] {
+				h.deliver(c, data)
+			}
+		}
+	} else {
+		for c := range h.byConv[evt.ConversationID] {
+			h.deliver(c, data)
+		}
+	}
+	h.mu.RUnlock()
+
+	if evt.Unsubscribes() {
+		h.unsubscribeUsers(evt.ConversationID, evt.UserIDs)
+	}
+}
+
+// Close disconnects every client, used on server shutdown.
+func (h *Hub) Close() {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
+
+	for _, c := range clients {
+		h.Unregister(c)
+	}
+}
+
+func (h *Hub) subscribeUsers(convID uint64, userIDs []uint64) {
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+
+	subscribe := false
+
+	h.mu.Lock()
+	for _, userID := range userIDs {
+		for c := range h.byUser[userID] {
+			c.convs[convID] = struct{}{}
+			if addToSet(h.byConv, convID, c) {
+				subscribe = true
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	if !subscribe {
+		return
+	}
+	if err := h.bus.Subscribe(context.Background(), eventbus.ConversationChannel(convID)); err != nil {
+		h.logger.Error().Err(err).Uint64("conversation_id", convID).Msg("failed to subscribe to conversation channel")
+	}
+}
+
+func (h *Hub) unsubscribeUsers(convID uint64, userIDs []uint64) {
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+
+	unsubscribe := false
+
+	h.mu.Lock()
+	for _, userID := range userIDs {
+		for c := range h.byUser[userID] {
+			delete(c.convs, convID)
+			if removeFromSet(h.byConv, convID, c) {
+				unsubscribe = true
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	if !unsubscribe {
+		return
+	}
+	if err := h.bus.Unsubscribe(context.Background(), eventbus.ConversationChannel(convID)); err != nil {
+		h.logger.Error().Err(err).Uint64("conversation_id", convID).Msg("failed to unsubscribe from conversation channel")
+	}
+}
+
+// deliver must be called with h.mu held. A client that cannot keep up is dropped
+// instead of blocking delivery to everyone else.
+func (h *Hub) deliver(c *Client, data []byte) {
+	select {
+	case c.send <- data:
+	default:
+		h.logger.Warn().Uint64("user_id", c.userID).Uint64("session_id", c.sessionID).Msg("websocket client is too slow, dropping connection")
+		go h.Unregister(c)
+	}
+}
+
+// addToSet reports whether the key had no clients before.
+func addToSet(m map[uint64]map[*Client]struct{}, key uint64, c *Client) bool {
+	set, ok := m[key]
+	if !ok {
+		set = make(map[*Client]struct{})
+		m[key] = set
+	}
+	set[c] = struct{}{}
+	return !ok
+}
+
+// removeFromSet reports whether the key has no clients left.
+func removeFromSet(m map[uint64]map[*Client]struct{}, key uint64, c *Client) bool {
+	set, ok := m[key]
+	if !ok {
+		return false
+	}
+	delete(set, c)
+	if len(set) == 0 {
+		delete(m, key)
+		return true
+	}
+	return false
+}
+
+// recentIDs remembers the last n event IDs, since an event published to several
+// channels this instance listens to arrives once per channel.
+type recentIDs struct {
+	mu   sync.Mutex
+	ids  map[string]struct{}
+	ring []string
+	next int
+}
+
+func newRecentIDs(n int) *recentIDs {
+	return &recentIDs{
+		ids:  make(map[string]struct{}, n),
+		ring: make([]string, n),
+	}
+}
+
+// add reports whether the id was not seen before.
+func (r *recentIDs) add(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ids[id]; ok {
+		return false
+	}
+	if old := r.ring[r.next]; old != "" {
+		delete(r.ids, old)
+	}
+	r.ring[r.next] = id
+	r.ids[id] = struct{}{}
+	r.next = (r.next + 1) % len(r.ring)
+	return true
+}
@@ -13,6 +13,10 @@ type VerifyUserRequest struct {
 	Code  int    `json:"code" binding:"required"`
 }
 
+type ResendCodeRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
 type VerifyUserResponse struct {
 	AccessToken     string `json:"access_token"`
 	RefreshToken    string `json:"refresh_token"`
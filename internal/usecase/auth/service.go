@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/mailer"
 	authRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/auth"
 	sessionInfra "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/session"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/uow"
@@ -9,6 +12,23 @@ import (
 	"github.com/rs/zerolog"
 )
 
+const (
+	// codeTTL is how long an emailed verification code stays valid.
+	codeTTL = 5 * time.Minute
+
+	// resendCooldown is the minimum time between two codes sent to the same address.
+	resendCooldown = time.Minute
+
+	// Verification attempts are counted per email, and failed ones per client IP, within
+	// attemptWindow. Reaching the email limit invalidates the code and locks the
+	// address; reaching the IP limit locks the client out of every address.
+	attemptWindow    = 15 * time.Minute
+	maxEmailAttempts = 5
+	maxIPAttempts    = 20
+	emailLockout     = 15 * time.Minute
+	ipLockout        = time.Hour
+)
+
 type AuthUsecase struct {
 	uow        uow.UnitOfWork
 	authStore  authRepo.AuthStore
@@ -18,12 +38,13 @@ type AuthUsecase struct {
 	token      security.TokenStore
 	logger     zerolog.Logger
 	codeHasher security.CodeHasher
+	mailer     mailer.Mailer
 }
 
 func NewAuthUsecase(authStore authRepo.AuthStore,
 	session sessionInfra.SessionStore, redis redisStore.OTPStore,
 	token security.TokenStore, hasher security.Hasher,
-	logger zerolog.Logger, codeHasher security.CodeHasher, uow uow.UnitOfWork) *AuthUsecase {
+	logger zerolog.Logger, codeHasher security.CodeHasher, uow uow.UnitOfWork, mailer mailer.Mailer) *AuthUsecase {
 
 	return &AuthUsecase{
 		authStore:  authStore,
@@ -34,5 +55,6 @@ func NewAuthUsecase(authStore authRepo.AuthStore,
 		logger:     logger,
 		codeHasher: codeHasher,
 		uow:        uow,
+		mailer:     mailer,
 	}
 }
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
-	"github.com/redis/go-redis/v9"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/mailer"
+	redisStore "github.com/Jaxongir1006/Chat-X-v2/internal/infra/redis/store"
 )
 
 func (a *AuthUsecase) Register(ctx context.Context, req RegisterRequest) error {
@@ -62,38 +64,171 @@ func (a *AuthUsecase) Register(ctx context.Context, req RegisterRequest) error {
 		return err
 	}
 
-	email := req.Email
-	go func(email string) {
-		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	// the account exists either way, a failed send can be retried with ResendCode
+	if err := a.sendCode(ctx, req.Email); err != nil {
+		a.logger.Error().Err(err).Str("email", req.Email).Msg("failed to send verification code")
+	}
+
+	return nil
+}
+
+// ResendCode sends a new verification code to an unverified account, at most once per
+// resendCooldown. Unknown and verified addresses and requests within the cooldown are
+// silently ignored, every address gets the same answer so the endpoint cannot be used
+// to probe for accounts.
+func (a *AuthUsecase) ResendCode(ctx context.Context, req ResendCodeRequest) error {
+	user, err := a.authStore.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if user.Verified {
+		return nil
+	}
+
+	if err := a.sendCode(ctx, req.Email); err != nil {
+		if apperr.Is(err, apperr.CodeRateLimited) {
+			return nil
+		}
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	return nil
+}
+
+// checkLocked rejects verification while the address or the client is locked out.
+func (a *AuthUsecase) checkLocked(ctx context.Context, email, ip string) error {
+	locks := []struct {
+		scope redisStore.OTPScope
+		id    string
+	}{{redisStore.OTPScopeEmail, email}, {redisStore.OTPScopeIP, ip}}
+
+	for _, l := range locks {
+		if l.id == "" {
+			continue
+		}
+		remaining, err := a.redis.LockedFor(ctx, l.scope, l.id)
+		if err != nil {
+			return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+		}
+		if remaining > 0 {
+			return rateLimited("too many failed attempts, try again later", remaining)
+		}
+	}
+	return nil
+}
+
+// reserveAttempt counts a verification attempt for the address before the code is
+// compared, so concurrent guesses cannot all be checked against the same count. The
+// attempt is rejected once the address is out of attempts. It returns the count.
+func (a *AuthUsecase) reserveAttempt(ctx context.Context, email, ip string) (int64, error) {
+	attempts, err := a.redis.IncrFailedAttempts(ctx, redisStore.OTPScopeEmail, email, attemptWindow)
+	if err != nil {
+		return 0, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if attempts > maxEmailAttempts {
+		return 0, rateLimited("too many failed attempts, try again later", emailLockout)
+	}
+	// locking clears the count, an attempt counted after a concurrent lock starts over
+	if err := a.checkLocked(ctx, email, ip); err != nil {
+		return 0, err
+	}
+	return attempts, nil
+}
 
-		code := generateRandomCode()
-		hashedCode := a.codeHasher.Hash(fmt.Sprintf("%d", code))
+// failedAttempt handles a wrong code for an attempt reserved with reserveAttempt. It
+// locks the address or the client once they run out of attempts and returns the error
+// to report for the attempt.
+func (a *AuthUsecase) failedAttempt(ctx context.Context, email, ip string, emailAttempts int64) error {
+	if ip != "" {
+		ipFails, err := a.redis.IncrFailedAttempts(ctx, redisStore.OTPScopeIP, ip, attemptWindow)
+		if err != nil {
+			return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+		}
+		if ipFails >= maxIPAttempts {
+			if err := a.redis.Lock(ctx, redisStore.OTPScopeIP, ip, ipLockout); err != nil {
+				return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+			}
+			a.logger.Warn().Str("ip", ip).Msg("client locked out of otp verification")
+			return rateLimited("too many failed attempts, try again later", ipLockout)
+		}
+	}
 
-		if err := a.redis.SaveEmailCode(bgCtx, email, hashedCode, 5*time.Minute); err != nil {
-			a.logger.Error().Err(err).Str("email", email).Int("code", code).Msg("failed to save email code to redis")
-			return
+	if emailAttempts >= maxEmailAttempts {
+		// the code may have leaked partially through the attempts, a new one must be requested
+		if err := a.redis.DeleteEmailCode(ctx, email); err != nil {
+			return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
 		}
+		if err := a.redis.Lock(ctx, redisStore.OTPScopeEmail, email, emailLockout); err != nil {
+			return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+		}
+		return rateLimited("too many failed attempts, the code is no longer valid", emailLockout)
+	}
+
+	invalid := apperr.New(apperr.CodeConflict, http.StatusConflict, "email code is invalid")
+	invalid.Fields = map[string]string{"attempts_left": strconv.FormatInt(maxEmailAttempts-emailAttempts, 10)}
+	return invalid
+}
+
+func rateLimited(msg string, retryAfter time.Duration) error {
+	err := apperr.New(apperr.CodeRateLimited, http.StatusTooManyRequests, msg)
+	err.Fields = map[string]string{"retry_after": strconv.Itoa(int(retryAfter.Round(time.Second).Seconds()))}
+	return err
+}
+
+// sendCode replaces the verification code of email and queues the email carrying it.
+func (a *AuthUsecase) sendCode(ctx context.Context, email string) error {
+	remaining, err := a.redis.StartResendCooldown(ctx, email, resendCooldown)
+	if err != nil {
+		return fmt.Errorf("start resend cooldown: %w", err)
+	}
+	if remaining > 0 {
+		return rateLimited("a code was sent recently, try again later", remaining)
+	}
 
-		a.logger.Debug().Str("email", email).Int("code", code).Msg("email code saved to redis")
-		// send email here
-	}(email)
+	code := generateRandomCode()
+	if err := a.redis.SaveEmailCode(ctx, email, a.codeHasher.Hash(fmt.Sprintf("%d", code)), codeTTL); err != nil {
+		return fmt.Errorf("save email code: %w", err)
+	}
 
+	msg, err := mailer.Render(mailer.TemplateVerificationCode, email, map[string]any{
+		"Code":      code,
+		"ExpiresIn": fmt.Sprintf("%d minutes", int(codeTTL.Minutes())),
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("queue verification email: %w", err)
+	}
 	return nil
 }
 
 func (a *AuthUsecase) VerifyUser(ctx context.Context, email string, code int, meta SessionMeta) (*VerifyUserResponse, error) {
 	// 1) Validate OTP from redis (outside tx)
+	if err := a.checkLocked(ctx, email, meta.IP); err != nil {
+		return nil, err
+	}
+
 	codeHash, err := a.redis.GetEmailCodeHash(ctx, email)
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "email code not found")
-		}
 		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
 	}
+	if codeHash == "" {
+		return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "email code not found")
+	}
 
+	attempts, err := a.reserveAttempt(ctx, email, meta.IP)
+	if err != nil {
+		return nil, err
+	}
 	if ok := a.codeHasher.Compare(fmt.Sprintf("%d", code), codeHash); !ok {
-		return nil, apperr.New(apperr.CodeConflict, http.StatusConflict, "email code is invalid")
+		return nil, a.failedAttempt(ctx, email, meta.IP, attempts)
+	}
+
+	if err := a.redis.ResetFailedAttempts(ctx, redisStore.OTPScopeEmail, email); err != nil {
+		a.logger.Error().Err(err).Msg("failed to reset otp attempts")
 	}
 
 	var resp *VerifyUserResponse
@@ -0,0 +1,158 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+)
+
+type action int
+
+const (
+	actionRead action = iota
+	actionWrite
+)
+
+// membership is the caller's view of a conversation after authorization.
+type membership struct {
+	conv *domain.Conversation
+	part *domain.Participant
+}
+
+// historyFrom is the oldest point in time the member may read. Group members
+// only see messages sent after they joined; DMs and channels expose the whole history.
+func (m membership) historyFrom() time.Time {
+	if m.conv.Type == domain.ConversationTypeDM || m.conv.Type == domain.ConversationTypeChannel {
+		return time.Time{}
+	}
+	return m.part.JoinedAt
+}
+
+// authorize checks that the user may perform the action in the conversation.
+// Users who are not active participants get CodeChatNotFound, so the existence
+// of conversations they cannot see is never revealed.
+func (u *ChatUsecase) authorize(ctx context.Context, conversationID, userID uint64, act action) (*membership, error) {
+	notFound := apperr.New(apperr.CodeChatNotFound, http.StatusNotFound, "chat not found")
+
+	part, err := u.chatStore.GetParticipant(ctx, conversationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, notFound
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	if part.LeftAt != nil || part.Role == domain.ParticipantRoleLeft || part.Role == domain.ParticipantRoleBanned {
+		return nil, notFound
+	}
+
+	conv, err := u.chatStore.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, notFound
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	member := &membership{conv: conv, part: part}
+	if act == actionWrite {
+		if member.subscriber() {
+			return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "only admins can post in this channel")
+		}
+
+		now := time.Now()
+		if part.Muted(now) {
+			return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you are muted in this chat")
+		}
+		if part.Restricted(domain.RestrictionMessages, now) {
+			return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you are not allowed to send messages to this chat")
+		}
+	}
+
+	return member, nil
+}
+
+// linkPattern matches the links the links restriction forbids: URLs with a scheme and
+// bare www. hosts.
+var linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)\S+`)
+
+// checkContent checks a message the member is about to post against the media and links
+// restrictions they are under.
+func (m membership) checkContent(typ domain.MessageType, text string) error {
+	now := time.Now()
+	if typ != domain.MessageTypeText && m.part.Restricted(domain.RestrictionMedia, now) {
+		return apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you are not allowed to send media to this chat")
+	}
+	if text != "" && m.part.Restricted(domain.RestrictionLinks, now) && linkPattern.MatchString(text) {
+		return apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you are not allowed to send links to this chat")
+	}
+	return nil
+}
+
+// authorizeMessage loads a message and authorizes the user in its conversation.
+// Messages the user cannot see are reported as not found.
+func (u *ChatUsecase) authorizeMessage(ctx context.Context, messageID, userID uint64, act action) (*domain.Message, *membership, error) {
+	msg, err := u.getMessage(ctx, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	member, err := u.authorize(ctx, msg.ConversationID, userID, act)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := u.checkVisible(ctx, member, msg); err != nil {
+		return nil, nil, err
+	}
+	return msg, member, nil
+}
+
+// visibleMessage returns a message of the member's conversation if they can see it.
+func (u *ChatUsecase) visibleMessage(ctx context.Context, m *membership, messageID uint64) (*domain.Message, error) {
+	msg, err := u.getMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkVisible(ctx, m, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// checkVisible reports a not found error unless the message belongs to the member's
+// conversation, is not deleted, was sent after their history starts and was not
+// deleted by them "for me".
+func (u *ChatUsecase) checkVisible(ctx context.Context, m *membership, msg *domain.Message) error {
+	notFound := apperr.New(apperr.CodeNotFound, http.StatusNotFound, "message not found")
+
+	if msg.ConversationID != m.conv.ID || msg.DeletedAt != nil || msg.CreatedAt.Before(m.historyFrom()) {
+		return notFound
+	}
+
+	hidden, err := u.chatStore.IsMessageHidden(ctx, msg.ID, m.part.UserID)
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if hidden {
+		return notFound
+	}
+	return nil
+}
+
+func (u *ChatUsecase) getMessage(ctx context.Context, messageID uint64) (*domain.Message, error) {
+	msg, err := u.chatStore.GetMessageByID(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "message not found")
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,157 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+)
+
+// mimePrefixes lists the content types each media message type accepts.
+// File messages accept anything.
+var mimePrefixes = map[domain.MessageType]string{
+	domain.MessageTypePhoto: "image/",
+	domain.MessageTypeVideo: "video/",
+	domain.MessageTypeVoice: "audio/",
+}
+
+// validateContent checks that the message type matches its text and attachments.
+func validateContent(typ domain.MessageType, text string, atts []AttachmentRequest) error {
+	switch typ {
+	case domain.MessageTypeText:
+		if text == "" {
+			return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "message text is required")
+		}
+		if len(atts) > 0 {
+			return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "text messages cannot have attachments")
+		}
+	case domain.MessageTypePhoto, domain.MessageTypeVideo, domain.MessageTypeFile:
+		if len(atts) == 0 || len(atts) > maxAttachments {
+			return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, fmt.Sprintf("message must have between 1 and %d attachments", maxAttachments))
+		}
+	case domain.MessageTypeVoice:
+		if len(atts) != 1 {
+			return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "voice message must have exactly one attachment")
+		}
+	default:
+		return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "unsupported message type")
+	}
+	return nil
+}
+
+// resolveAttachments verifies that the sender uploaded every referenced object as an
+// attachment and takes its size and content type from the media registry rather than
+// trusting the client.
+func (u *ChatUsecase) resolveAttachments(ctx context.Context, userID uint64, typ domain.MessageType, reqs []AttachmentRequest) ([]domain.Attachment, error) {
+	atts := make([]domain.Attachment, 0, len(reqs))
+	for _, req := range reqs {
+		obj, err := u.mediaStore.GetByKey(ctx, req.StorageKey)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "attachment not found")
+			}
+			return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+		}
+		if obj.OwnerID != userID {
+			return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "attachment does not belong to you")
+		}
+		if obj.Purpose != domain.MediaPurposeAttachment {
+			return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "media was not uploaded as an attachment")
+		}
+
+		if prefix, ok := mimePrefixes[typ]; ok && !strings.HasPrefix(obj.MimeType, prefix) {
+			return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "attachment type does not match the message type")
+		}
+
+		mimeType, size := obj.MimeType, obj.SizeBytes
+		att := domain.Attachment{
+			StorageKey: req.StorageKey,
+			MimeType:   &mimeType,
+			SizeBytes:  &size,
+			Width:      req.Width,
+			Height:     req.Height,
+		}
+		// measured dimensions win over what the client claims
+		if obj.Width != nil && obj.Height != nil {
+			att.Width, att.Height = obj.Width, obj.Height
+		}
+		atts = append(atts, att)
+	}
+	return atts, nil
+}
+
+func storageKeys(atts []domain.Attachment) []string {
+	keys := make([]string, 0, len(atts))
+	for _, a := range atts {
+		keys = append(keys, a.StorageKey)
+	}
+	return keys
+}
+
+// attachAttachments loads the attachments of a page of messages and presigns their URLs.
+func (u *ChatUsecase) attachAttachments(ctx context.Context, msgs []MessageResponse, variant domain.MediaVariant) error {
+	ids := make([]uint64, 0, len(msgs))
+	for _, m := range msgs {
+		if m.Type != domain.MessageTypeText && m.Type != domain.MessageTypeSystem {
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	atts, err := u.chatStore.GetAttachments(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		if msgs[i].Attachments, err = u.toAttachmentResponses(ctx, atts[msgs[i].ID], variant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toAttachmentResponses presigns the requested variant of every attachment, or the
+// original for files and images whose thumbnails are not ready yet.
+func (u *ChatUsecase) toAttachmentResponses(ctx context.Context, atts []domain.Attachment, variant domain.MediaVariant) ([]AttachmentResponse, error) {
+	if len(atts) == 0 {
+		return nil, nil
+	}
+
+	objs, err := u.mediaStore.GetByKeys(ctx, storageKeys(atts))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]AttachmentResponse, 0, len(atts))
+	for _, a := range atts {
+		key, served := a.StorageKey, domain.VariantOriginal
+		var placeholder *string
+		if obj, ok := objs[a.StorageKey]; ok {
+			key, served = obj.ServeKey(variant)
+			placeholder = obj.Placeholder
+		}
+
+		url, err := u.storage.PresignGet(ctx, key, attachmentURLExpiry)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, AttachmentResponse{
+			ID:          a.ID,
+			URL:         url,
+			Variant:     served,
+			MimeType:    a.MimeType,
+			SizeBytes:   a.SizeBytes,
+			Width:       a.Width,
+			Height:      a.Height,
+			Placeholder: placeholder,
+		})
+	}
+	return resp, nil
+}
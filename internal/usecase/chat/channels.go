@@ -0,0 +1,159 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	chatRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/chat"
+)
+
+// usernamePattern is what a channel @username may look like once lowercased.
+var usernamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{4,31}$`)
+
+// CreateChannel creates a broadcast channel owned by the user. Channels have no member
+// limit: subscribers only read and react, and posts reach them without a row per
+// subscriber.
+func (u *ChatUsecase) CreateChannel(ctx context.Context, userID uint64, req CreateChannelRequest) (*ChannelResponse, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "title is required")
+	}
+
+	conv := domain.Conversation{
+		Type:      domain.ConversationTypeChannel,
+		Title:     &title,
+		CreatedBy: userID,
+	}
+	if desc := strings.TrimSpace(req.Description); desc != "" {
+		conv.Description = &desc
+	}
+	if req.Username != nil {
+		username, err := normalizeUsername(*req.Username)
+		if err != nil {
+			return nil, err
+		}
+		conv.Username, conv.IsPublic = &username, true
+	}
+
+	var evt domain.Event
+	err := u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		if err := chatTx.CreateConversation(ctx, &conv); err != nil {
+			return err
+		}
+		owner := domain.Participant{ConversationID: conv.ID, UserID: userID, Role: domain.ParticipantRoleOwner}
+		if err := chatTx.AddParticipant(ctx, &owner); err != nil {
+			return err
+		}
+		conv.MemberCount = 1
+
+		// subscribes the owner's open connections to the new channel
+		evt = newEvent(domain.EventParticipantAdded, conv.ID, []uint64{userID}, toChannelResponse(conv))
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		if errors.Is(err, chatRepo.ErrUsernameTaken) {
+			return nil, apperr.New(apperr.CodeConflict, http.StatusConflict, "username is taken")
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to create channel", err)
+	}
+
+	u.publish(ctx, evt)
+
+	return toChannelResponse(conv), nil
+}
+
+// GetChannel looks up a public channel by its @username.
+func (u *ChatUsecase) GetChannel(ctx context.Context, username string) (*ChannelResponse, error) {
+	notFound := apperr.New(apperr.CodeChatNotFound, http.StatusNotFound, "channel not found")
+
+	username, err := normalizeUsername(username)
+	if err != nil {
+		return nil, notFound
+	}
+
+	conv, err := u.chatStore.GetConversationByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, notFound
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if conv.Type != domain.ConversationTypeChannel || !conv.IsPublic {
+		return nil, notFound
+	}
+	return toChannelResponse(*conv), nil
+}
+
+// Subscribe subscribes the user to a public channel. Private channels are joined by
+// invite link.
+func (u *ChatUsecase) Subscribe(ctx context.Context, userID uint64, req SubscribeRequest) (*JoinResponse, error) {
+	notFound := apperr.New(apperr.CodeChatNotFound, http.StatusNotFound, "channel not found")
+
+	conv, err := u.chatStore.GetConversationByID(ctx, req.ConversationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, notFound
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if conv.Type != domain.ConversationTypeChannel || !conv.IsPublic {
+		return nil, notFound
+	}
+
+	resp := &JoinResponse{ConversationID: conv.ID, Status: JoinStatusJoined}
+	joined, err := u.checkJoin(ctx, conv.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if joined {
+		return resp, nil
+	}
+
+	var evt domain.Event
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		part := domain.Participant{ConversationID: conv.ID, UserID: userID, Role: domain.ParticipantRoleMember}
+		if err := u.chatStore.WithTx(tx).AddParticipant(ctx, &part); err != nil {
+			return err
+		}
+
+		evt = joinedEvent(conv, []uint64{userID}, &MembersChangedPayload{ActorID: userID, Members: []MemberResponse{toMemberResponse(part)}})
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to subscribe", err)
+	}
+
+	u.publish(ctx, evt)
+
+	return resp, nil
+}
+
+// normalizeUsername strips the leading @ and lowercases the username, so lookups are
+// case insensitive.
+func normalizeUsername(username string) (string, error) {
+	username = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+	if !usernamePattern.MatchString(username) {
+		return "", apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "username must be 5 to 32 letters, digits or underscores and start with a letter")
+	}
+	return username, nil
+}
+
+func toChannelResponse(c domain.Conversation) *ChannelResponse {
+	return &ChannelResponse{
+		ID:              c.ID,
+		Title:           c.Title,
+		Username:        c.Username,
+		Description:     c.Description,
+		IsPublic:        c.IsPublic,
+		SubscriberCount: c.MemberCount,
+		CreatedAt:       c.CreatedAt,
+	}
+}
@@ -12,10 +12,280 @@ type CreateGroupRequest struct {
 	UserIDs     []uint64 `json:"user_ids"`
 }
 
+// CreateChannelRequest creates a broadcast channel. A channel with a username is public
+// and anyone can subscribe to it; one without is joined by invite link or join request.
+type CreateChannelRequest struct {
+	Title       string  `json:"title" binding:"required"`
+	Description string  `json:"description"`
+	Username    *string `json:"username"`
+}
+
+type SubscribeRequest struct {
+	ConversationID uint64 `json:"conversation_id" binding:"required"`
+}
+
+type ChannelResponse struct {
+	ID              uint64    `json:"id"`
+	Title           *string   `json:"title"`
+	Username        *string   `json:"username,omitempty"`
+	Description     *string   `json:"description,omitempty"`
+	IsPublic        bool      `json:"is_public"`
+	SubscriberCount int64     `json:"subscriber_count"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type AddMembersRequest struct {
+	ConversationID uint64   `json:"conversation_id" binding:"required"`
+	UserIDs        []uint64 `json:"user_ids" binding:"required"`
+}
+
+// MemberRequest names a member of a group, for removing them or handing them ownership.
+type MemberRequest struct {
+	ConversationID uint64 `json:"conversation_id" binding:"required"`
+	UserID         uint64 `json:"user_id" binding:"required"`
+}
+
+type SetMemberRoleRequest struct {
+	ConversationID uint64                 `json:"conversation_id" binding:"required"`
+	UserID         uint64                 `json:"user_id" binding:"required"`
+	Role           domain.ParticipantRole `json:"role" binding:"required"` // admin or member
+}
+
+type LeaveChatRequest struct {
+	ConversationID uint64 `json:"conversation_id" binding:"required"`
+}
+
+type MemberResponse struct {
+	UserID     uint64                 `json:"user_id"`
+	Role       domain.ParticipantRole `json:"role"`
+	JoinedAt   time.Time              `json:"joined_at"`
+	MutedUntil *time.Time             `json:"muted_until,omitempty"`
+
+	Restrictions    []domain.Restriction `json:"restrictions,omitempty"`
+	RestrictedUntil *time.Time           `json:"restricted_until,omitempty"`
+}
+
+// MembersChangedPayload is the payload of participant.added and participant.updated events.
+type MembersChangedPayload struct {
+	ActorID uint64           `json:"actor_id"`
+	Members []MemberResponse `json:"members"`
+}
+
+// MemberRemovedPayload is the payload of participant.removed events; ActorID equals
+// UserID when the member left on their own.
+type MemberRemovedPayload struct {
+	ActorID uint64 `json:"actor_id"`
+	UserID  uint64 `json:"user_id"`
+}
+
+// ModerationRequest targets a user of a group with a moderation action. Duration is in
+// seconds; zero bans or restricts for good but is not allowed for a mute. Restrictions
+// only apply to restrict.
+type ModerationRequest struct {
+	ConversationID uint64               `json:"conversation_id" binding:"required"`
+	UserID         uint64               `json:"user_id" binding:"required"`
+	Duration       int64                `json:"duration"`
+	Restrictions   []domain.Restriction `json:"restrictions"`
+	Reason         *string              `json:"reason"`
+}
+
+type GetMembersRequest struct {
+	ConversationID uint64
+	AfterID        uint64
+	Limit          int
+}
+
+type MembersResponse struct {
+	Members    []MemberResponse `json:"members"`     // owner and admins first, then by join time
+	NextCursor *uint64          `json:"next_cursor"` // pass as after_id to load the next page
+}
+
+type GetModerationLogRequest struct {
+	ConversationID uint64
+	BeforeID       uint64
+	Limit          int
+}
+
+type ModerationLogResponse struct {
+	Actions    []ModerationActionResponse `json:"actions"`     // newest first
+	NextCursor *uint64                    `json:"next_cursor"` // pass as before_id to load older entries
+}
+
+type ModerationActionResponse struct {
+	ID           uint64                      `json:"id"`
+	ActorID      uint64                      `json:"actor_id"`
+	UserID       uint64                      `json:"user_id"`
+	Action       domain.ModerationActionType `json:"action"`
+	Restrictions []domain.Restriction        `json:"restrictions,omitempty"`
+	Until        *time.Time                  `json:"until,omitempty"`
+	Reason       *string                     `json:"reason,omitempty"`
+	CreatedAt    time.Time                   `json:"created_at"`
+}
+
+// ModerationNotice is the text of the system message announcing a moderation action,
+// encoded as JSON so clients can word it themselves. The reason stays in the log.
+type ModerationNotice struct {
+	Action       domain.ModerationActionType `json:"action"`
+	ActorID      uint64                      `json:"actor_id"`
+	UserID       uint64                      `json:"user_id"`
+	Restrictions []domain.Restriction        `json:"restrictions,omitempty"`
+	Until        *time.Time                  `json:"until,omitempty"`
+}
+
+// CreateInviteLinkRequest creates an invite link. ExpiresIn is in seconds; zero
+// ExpiresIn or MaxUses means no limit.
+type CreateInviteLinkRequest struct {
+	ConversationID   uint64 `json:"conversation_id" binding:"required"`
+	ExpiresIn        int64  `json:"expires_in"`
+	MaxUses          int    `json:"max_uses"`
+	RequiresApproval bool   `json:"requires_approval"`
+}
+
+type RevokeInviteLinkRequest struct {
+	ConversationID uint64 `json:"conversation_id" binding:"required"`
+	LinkID         uint64 `json:"link_id" binding:"required"`
+}
+
+// JoinByInviteRequest joins a chat by invite code. Message is passed on to the admins
+// when the link requires approval.
+type JoinByInviteRequest struct {
+	Code    string  `json:"code" binding:"required"`
+	Message *string `json:"message"`
+}
+
+type InviteLinkResponse struct {
+	ID               uint64     `json:"id"`
+	ConversationID   uint64     `json:"conversation_id"`
+	Code             string     `json:"code"`
+	CreatedBy        uint64     `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	MaxUses          *int       `json:"max_uses,omitempty"`
+	UsesCount        int        `json:"uses_count"`
+	IsActive         bool       `json:"is_active"`
+	RequiresApproval bool       `json:"requires_approval"`
+}
+
+type JoinStatus string
+
+const (
+	JoinStatusJoined  JoinStatus = "joined"
+	JoinStatusPending JoinStatus = "pending" // waiting for an admin to approve
+)
+
+type JoinResponse struct {
+	ConversationID uint64     `json:"conversation_id"`
+	Status         JoinStatus `json:"status"`
+}
+
+// JoinRequestRequest asks to join a public group or channel, named by ID or @username.
+type JoinRequestRequest struct {
+	ConversationID uint64  `json:"conversation_id"`
+	Username       *string `json:"username"`
+	Message        *string `json:"message"`
+}
+
+// JoinRequestsDecisionRequest approves or declines the requests of the listed users.
+type JoinRequestsDecisionRequest struct {
+	ConversationID uint64   `json:"conversation_id" binding:"required"`
+	UserIDs        []uint64 `json:"user_ids" binding:"required"`
+}
+
+type JoinRequestResponse struct {
+	ConversationID uint64    `json:"conversation_id"`
+	UserID         uint64    `json:"user_id"`
+	RequestedAt    time.Time `json:"requested_at"`
+	Message        *string   `json:"message,omitempty"`
+}
+
+// JoinDecisionPayload is the payload of join_request.approved and join_request.declined
+// events.
+type JoinDecisionPayload struct {
+	ActorID uint64   `json:"actor_id"`
+	UserIDs []uint64 `json:"user_ids"`
+}
+
 type StartDMRequest struct {
 	UserID uint64 `json:"user_id" binding:"required"`
 }
 
+// SendMessageRequest sends a text message, or a photo, video, file or voice message
+// made of previously uploaded objects with Text as an optional caption.
+type SendMessageRequest struct {
+	ConversationID uint64              `json:"conversation_id" binding:"required"`
+	Type           domain.MessageType  `json:"type"` // defaults to text
+	Text           string              `json:"text"`
+	Attachments    []AttachmentRequest `json:"attachments"`
+	ReplyToID      *uint64             `json:"reply_to_id"`
+}
+
+// AttachmentRequest references an object the sender uploaded through the media API.
+type AttachmentRequest struct {
+	StorageKey string `json:"storage_key" binding:"required"`
+	Width      *int   `json:"width"`
+	Height     *int   `json:"height"`
+}
+
+// ForwardMessagesRequest copies messages into other conversations. The messages are
+// forwarded in the order they were sent.
+type ForwardMessagesRequest struct {
+	MessageIDs      []uint64 `json:"message_ids" binding:"required"`
+	ConversationIDs []uint64 `json:"conversation_ids" binding:"required"`
+}
+
+type EditMessageRequest struct {
+	MessageID uint64 `json:"message_id" binding:"required"`
+	Text      string `json:"text" binding:"required"`
+}
+
+// DeleteMessageRequest deletes a message for everyone in the conversation, or only
+// hides it from the caller's own history when ForEveryone is false.
+type DeleteMessageRequest struct {
+	MessageID   uint64 `json:"message_id" binding:"required"`
+	ForEveryone bool   `json:"for_everyone"`
+}
+
+type ReactionRequest struct {
+	MessageID uint64 `json:"message_id" binding:"required"`
+	Emoji     string `json:"emoji" binding:"required"`
+}
+
+type ReactionResponse struct {
+	UserID    uint64    `json:"user_id"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MarkReadRequest marks the conversation read up to and including MessageID.
+type MarkReadRequest struct {
+	ConversationID uint64 `json:"conversation_id" binding:"required"`
+	MessageID      uint64 `json:"message_id" binding:"required"`
+}
+
+type ReadReceiptResponse struct {
+	UserID uint64    `json:"user_id"`
+	ReadAt time.Time `json:"read_at"`
+}
+
+// GetMessagesRequest pages through history with at most one cursor:
+// BeforeID loads older messages, AfterID newer ones and AroundID centers the page on a message.
+// Without a cursor the latest messages are returned.
+type GetMessagesRequest struct {
+	ConversationID uint64
+	BeforeID       uint64
+	AfterID        uint64
+	AroundID       uint64
+	Limit          int
+	// Variant selects the image rendition the attachment URLs point at.
+	Variant domain.MediaVariant
+}
+
+type MessageHistoryResponse struct {
+	Messages   []MessageResponse `json:"messages"`    // newest first
+	NextCursor *uint64           `json:"next_cursor"` // pass as before_id to load older messages
+	PrevCursor *uint64           `json:"prev_cursor"` // pass as after_id to load newer messages
+}
+
 type MessageResponse struct {
 	ID             uint64             `json:"id"`
 	ConversationID uint64             `json:"conversation_id"`
@@ -23,12 +293,62 @@ type MessageResponse struct {
 	Type           domain.MessageType `json:"type"`
 	Text           *string            `json:"text"`
 	CreatedAt      time.Time          `json:"created_at"`
+	EditedAt       *time.Time         `json:"edited_at,omitempty"`
+	ReplyToID      *uint64            `json:"reply_to_id,omitempty"`
+	ForwardFromID  *uint64            `json:"forward_from_id,omitempty"`
+
+	ReplyTo     *MessagePreview        `json:"reply_to,omitempty"`
+	ForwardFrom *MessagePreview        `json:"forward_from,omitempty"`
+	Attachments []AttachmentResponse   `json:"attachments,omitempty"`
+	Reactions   []domain.ReactionCount `json:"reactions,omitempty"`
+}
+
+type AttachmentResponse struct {
+	ID          uint64              `json:"id"`
+	URL         string              `json:"url"`     // presigned, valid for a limited time
+	Variant     domain.MediaVariant `json:"variant"` // the rendition URL points at
+	MimeType    *string             `json:"mime_type"`
+	SizeBytes   *int64              `json:"size_bytes"`
+	Width       *int                `json:"width,omitempty"`
+	Height      *int                `json:"height,omitempty"`
+	Placeholder *string             `json:"placeholder,omitempty"`
+}
+
+// MessagePreview quotes the original of a reply or forward. A reply quotes the original
+// as it is now, and Snippet is empty when it has been deleted; a forward quotes it as it
+// was when forwarded.
+type MessagePreview struct {
+	ID       uint64             `json:"id"`
+	SenderID *uint64            `json:"sender_id"`
+	Type     domain.MessageType `json:"type"`
+	Snippet  *string            `json:"snippet"`
+	Deleted  bool               `json:"deleted"`
+}
+
+type DeletedMessageResponse struct {
+	ID             uint64 `json:"id"`
+	ConversationID uint64 `json:"conversation_id"`
+}
+
+type MessageEditResponse struct {
+	ID       uint64    `json:"id"`
+	EditorID uint64    `json:"editor_id"`
+	OldText  *string   `json:"old_text"`
+	EditedAt time.Time `json:"edited_at"`
 }
 
 type ConversationResponse struct {
 	ID            uint64                  `json:"id"`
 	Type          domain.ConversationType `json:"type"`
 	Title         *string                 `json:"title"`
+	Username      *string                 `json:"username,omitempty"`
+	MemberCount   int64                   `json:"member_count"`
 	LastMessageID *uint64                 `json:"last_message_id"`
+	UnreadCount   int                     `json:"unread_count"` // 100 means more than 99
 	UpdatedAt     time.Time               `json:"updated_at"`
 }
+
+type ConversationListResponse struct {
+	Conversations []ConversationResponse `json:"conversations"`
+	TotalUnread   int                    `json:"total_unread"` // sum of the capped counts
+}
@@ -0,0 +1,62 @@
+package chat
+
+import "unicode"
+
+const zeroWidthJoiner = '\u200d'
+
+// validReaction reports whether the emoji may be used as a reaction: one of the
+// configured emoji, or any single grapheme when no set is configured.
+func (u *ChatUsecase) validReaction(emoji string) bool {
+	if u.allowedReactions != nil {
+		_, ok := u.allowedReactions[emoji]
+		return ok
+	}
+	return isSingleGrapheme(emoji)
+}
+
+// isSingleGrapheme reports whether s is displayed as a single character: a base rune
+// followed only by combining marks, variation selectors, skin tone modifiers or tags,
+// possibly joined with other such clusters by ZWJ, or a flag made of two regional
+// indicators. It is a simplified form of the UAX #29 rules that covers emoji.
+func isSingleGrapheme(s string) bool {
+	if s == "" || len(s) > maxReactionBytes {
+		return false
+	}
+
+	runes := []rune(s)
+	if isRegionalIndicator(runes[0]) {
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	}
+	if !isGraphemeBase(runes[0]) {
+		return false
+	}
+
+	for i := 1; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == zeroWidthJoiner:
+			if i+1 >= len(runes) || !isGraphemeBase(runes[i+1]) {
+				return false
+			}
+			i++
+		case isGraphemeExtend(r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isGraphemeBase(r rune) bool {
+	return unicode.IsGraphic(r) && !unicode.IsSpace(r) && !isGraphemeExtend(r) && !isRegionalIndicator(r)
+}
+
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me) ||
+		(r >= 0xFE00 && r <= 0xFE0F) || // variation selectors
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // skin tone modifiers
+		(r >= 0xE0020 && r <= 0xE007F) // tags, used by subdivision flags
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
+
+// outboxTopicChat is the logical Kafka topic of chat events, resolved with KafkaConfig.ResolveTopic.
+const outboxTopicChat = "chat"
+
+func newEvent(typ domain.EventType, conversationID uint64, userIDs []uint64, payload any) domain.Event {
+	return domain.Event{
+		ID:             newEventID(),
+		Type:           typ,
+		ConversationID: conversationID,
+		UserIDs:        userIDs,
+		Payload:        payload,
+		OccurredAt:     time.Now(),
+	}
+}
+
+// joinedEvent announces users joining the conversation. Channel subscribers come and go
+// too often to tell everyone, so only their own clients hear of it.
+func joinedEvent(conv *domain.Conversation, userIDs []uint64, payload any) domain.Event {
+	if conv.Type == domain.ConversationTypeChannel {
+		return newEvent(domain.EventSubscribed, conv.ID, userIDs, payload)
+	}
+	return newEvent(domain.EventParticipantAdded, conv.ID, userIDs, payload)
+}
+
+// leftEvent announces a user leaving the conversation, see joinedEvent.
+func leftEvent(conv *domain.Conversation, userID uint64, payload any) domain.Event {
+	if conv.Type == domain.ConversationTypeChannel {
+		return newEvent(domain.EventUnsubscribed, conv.ID, []uint64{userID}, payload)
+	}
+	return newEvent(domain.EventParticipantRemoved, conv.ID, []uint64{userID}, payload)
+}
+
+// record writes the event to the outbox in the same transaction as the change it describes.
+// The event ID doubles as the idempotency key for Kafka consumers.
+func (u *ChatUsecase) record(ctx context.Context, tx *sql.Tx, evt domain.Event) error {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	return u.outbox.WithTx(tx).Insert(ctx, &domain.OutboxEvent{
+		IdempotencyKey: evt.ID,
+		Topic:          outboxTopicChat,
+		EventType:      string(evt.Type),
+		AggregateID:    evt.ConversationID,
+		Payload:        payload,
+	})
+}
+
+// publish pushes a realtime event after the change has been committed.
+// Delivery is best effort: a failure is logged and never fails the request.
+func (u *ChatUsecase) publish(ctx context.Context, evt domain.Event) {
+	if err := u.events.Publish(ctx, evt); err != nil {
+		u.logger.Error().Err(err).Str("event", string(evt.Type)).Uint64("conversation_id", evt.ConversationID).Msg("failed to publish chat event")
+	}
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
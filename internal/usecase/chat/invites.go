@@ -0,0 +1,190 @@
+package chat
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+)
+
+// CreateInviteLink creates an invite link for a group or channel.
+func (u *ChatUsecase) CreateInviteLink(ctx context.Context, userID uint64, req CreateInviteLinkRequest) (*InviteLinkResponse, error) {
+	if req.ExpiresIn < 0 || req.ExpiresIn > int64(maxInviteLinkTTL/time.Second) {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "expires_in is out of range")
+	}
+	if req.MaxUses < 0 || req.MaxUses > maxInviteLinkUses {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "max_uses is out of range")
+	}
+
+	member, err := u.authorizeGroup(ctx, req.ConversationID, userID, permManageInvites)
+	if err != nil {
+		return nil, err
+	}
+
+	link := domain.InviteLink{
+		ConversationID:   member.conv.ID,
+		Code:             rand.Text(),
+		CreatedBy:        userID,
+		RequiresApproval: req.RequiresApproval,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		link.ExpiresAt = &expiresAt
+	}
+	if req.MaxUses > 0 {
+		link.MaxUses = &req.MaxUses
+	}
+
+	if err := u.chatStore.CreateInviteLink(ctx, &link); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to create invite link", err)
+	}
+
+	resp := toInviteLinkResponse(link)
+	return &resp, nil
+}
+
+// GetInviteLinks lists the invite links of a group or channel, revoked ones included.
+func (u *ChatUsecase) GetInviteLinks(ctx context.Context, userID, conversationID uint64) ([]InviteLinkResponse, error) {
+	if _, err := u.authorizeGroup(ctx, conversationID, userID, permManageInvites); err != nil {
+		return nil, err
+	}
+
+	links, err := u.chatStore.GetInviteLinks(ctx, conversationID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := make([]InviteLinkResponse, 0, len(links))
+	for _, l := range links {
+		resp = append(resp, toInviteLinkResponse(l))
+	}
+	return resp, nil
+}
+
+func (u *ChatUsecase) RevokeInviteLink(ctx context.Context, userID uint64, req RevokeInviteLinkRequest) error {
+	if _, err := u.authorizeGroup(ctx, req.ConversationID, userID, permManageInvites); err != nil {
+		return err
+	}
+
+	ok, err := u.chatStore.RevokeInviteLink(ctx, req.ConversationID, req.LinkID)
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to revoke invite link", err)
+	}
+	if !ok {
+		return apperr.New(apperr.CodeNotFound, http.StatusNotFound, "invite link not found")
+	}
+	return nil
+}
+
+// JoinByInvite joins the chat of an invite link, or asks to join it when the link
+// requires approval. Users already in the chat are not counted against the link.
+func (u *ChatUsecase) JoinByInvite(ctx context.Context, userID uint64, req JoinByInviteRequest) (*JoinResponse, error) {
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "code is required")
+	}
+	if req.Message != nil && utf8.RuneCountInString(*req.Message) > maxJoinMessage {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "message is too long")
+	}
+
+	link, err := u.chatStore.GetInviteLinkByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "invite link not found")
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	expired := apperr.New(apperr.CodeNotFound, http.StatusGone, "invite link has expired")
+	if !link.Usable(time.Now()) {
+		return nil, expired
+	}
+
+	conv, err := u.chatStore.GetConversationByID(ctx, link.ConversationID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	joined, err := u.checkJoin(ctx, link.ConversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if joined {
+		return &JoinResponse{ConversationID: link.ConversationID, Status: JoinStatusJoined}, nil
+	}
+
+	resp := &JoinResponse{ConversationID: link.ConversationID, Status: JoinStatusJoined}
+	if link.RequiresApproval {
+		resp.Status = JoinStatusPending
+	}
+
+	var evt *domain.Event
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		// the use is counted in the same transaction, so a link is never used past its limit
+		use := func() error {
+			ok, err := chatTx.UseInviteLink(ctx, link.ID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return expired
+			}
+			return nil
+		}
+
+		if link.RequiresApproval {
+			var err error
+			evt, err = u.queueJoinRequest(ctx, tx, &domain.JoinRequest{ConversationID: link.ConversationID, UserID: userID, Message: req.Message})
+			if err != nil || evt == nil {
+				return err // already waiting, the link is not used again
+			}
+			return use()
+		}
+
+		if err := use(); err != nil {
+			return err
+		}
+		newPart := domain.Participant{ConversationID: link.ConversationID, UserID: userID, Role: domain.ParticipantRoleMember}
+		if err := chatTx.AddParticipant(ctx, &newPart); err != nil {
+			return err
+		}
+
+		added := joinedEvent(conv, []uint64{userID}, &MembersChangedPayload{
+			ActorID: userID,
+			Members: []MemberResponse{toMemberResponse(newPart)},
+		})
+		evt = &added
+		return u.record(ctx, tx, added)
+	})
+	if err != nil {
+		return nil, membershipError(err)
+	}
+
+	if evt != nil {
+		u.publish(ctx, *evt)
+	}
+	return resp, nil
+}
+
+func toInviteLinkResponse(l domain.InviteLink) InviteLinkResponse {
+	return InviteLinkResponse{
+		ID:               l.ID,
+		ConversationID:   l.ConversationID,
+		Code:             l.Code,
+		CreatedBy:        l.CreatedBy,
+		CreatedAt:        l.CreatedAt,
+		ExpiresAt:        l.ExpiresAt,
+		MaxUses:          l.MaxUses,
+		UsesCount:        l.UsesCount,
+		IsActive:         l.IsActive,
+		RequiresApproval: l.RequiresApproval,
+	}
+}
@@ -0,0 +1,310 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	chatRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/chat"
+)
+
+// RequestToJoin asks the admins of a public group or channel, found by ID or @username,
+// to let the user in. Private chats are only reachable through their invite links, so
+// they get the same not found as DMs and unknown chats. Asking again while a request is
+// pending changes nothing.
+func (u *ChatUsecase) RequestToJoin(ctx context.Context, userID uint64, req JoinRequestRequest) (*JoinResponse, error) {
+	if (req.ConversationID == 0) == (req.Username == nil) {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "either conversation_id or username is required")
+	}
+	if req.Message != nil && utf8.RuneCountInString(*req.Message) > maxJoinMessage {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "message is too long")
+	}
+
+	conv, err := u.joinableChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	joined, err := u.checkJoin(ctx, conv.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if joined {
+		return &JoinResponse{ConversationID: conv.ID, Status: JoinStatusJoined}, nil
+	}
+
+	var evt *domain.Event
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		var err error
+		evt, err = u.queueJoinRequest(ctx, tx, &domain.JoinRequest{ConversationID: conv.ID, UserID: userID, Message: req.Message})
+		return err
+	})
+	if err != nil {
+		return nil, membershipError(err)
+	}
+
+	if evt != nil {
+		u.publish(ctx, *evt)
+	}
+	return &JoinResponse{ConversationID: conv.ID, Status: JoinStatusPending}, nil
+}
+
+// joinableChat looks up the chat a join request is for. Anything but a public group or
+// channel is reported as not found.
+func (u *ChatUsecase) joinableChat(ctx context.Context, req JoinRequestRequest) (*domain.Conversation, error) {
+	notFound := apperr.New(apperr.CodeChatNotFound, http.StatusNotFound, "chat not found")
+
+	var (
+		conv *domain.Conversation
+		err  error
+	)
+	if req.Username != nil {
+		username, uerr := normalizeUsername(*req.Username)
+		if uerr != nil {
+			return nil, notFound
+		}
+		conv, err = u.chatStore.GetConversationByUsername(ctx, username)
+	} else {
+		conv, err = u.chatStore.GetConversationByID(ctx, req.ConversationID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, notFound
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if conv.Type == domain.ConversationTypeDM || !conv.IsPublic {
+		return nil, notFound
+	}
+	return conv, nil
+}
+
+// GetJoinRequests lists the pending join requests of a group or channel, oldest first.
+func (u *ChatUsecase) GetJoinRequests(ctx context.Context, userID, conversationID uint64) ([]JoinRequestResponse, error) {
+	if _, err := u.authorizeGroup(ctx, conversationID, userID, permApproveJoins); err != nil {
+		return nil, err
+	}
+
+	reqs, err := u.chatStore.GetJoinRequests(ctx, conversationID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := make([]JoinRequestResponse, 0, len(reqs))
+	for _, jr := range reqs {
+		resp = append(resp, JoinRequestResponse{
+			ConversationID: jr.ConversationID,
+			UserID:         jr.UserID,
+			RequestedAt:    jr.RequestedAt,
+			Message:        jr.Message,
+		})
+	}
+	return resp, nil
+}
+
+// ApproveJoinRequests adds the users whose requests are pending to the chat. Users
+// without a pending request are skipped, as are banned users, whose requests are
+// dropped.
+func (u *ChatUsecase) ApproveJoinRequests(ctx context.Context, userID uint64, req JoinRequestsDecisionRequest) ([]MemberResponse, error) {
+	member, ids, err := u.authorizeJoinDecision(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		added    []MemberResponse
+		evt      domain.Event
+		approved domain.Event
+	)
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		var addedIDs []uint64
+		for _, id := range ids {
+			ok, err := chatTx.DeleteJoinRequest(ctx, member.conv.ID, id)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			part, err := chatTx.GetParticipant(ctx, member.conv.ID, id)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if part != nil && (isActive(part) || part.Banned(time.Now())) {
+				continue
+			}
+
+			newPart := domain.Participant{ConversationID: member.conv.ID, UserID: id, Role: domain.ParticipantRoleMember}
+			if err := chatTx.AddParticipant(ctx, &newPart); err != nil {
+				return err
+			}
+			added = append(added, toMemberResponse(newPart))
+			addedIDs = append(addedIDs, id)
+		}
+		if len(addedIDs) == 0 {
+			return nil
+		}
+
+		// the approved users are subscribed to the chat, so they receive this event too
+		evt = joinedEvent(member.conv, addedIDs, &MembersChangedPayload{ActorID: userID, Members: added})
+		if err := u.record(ctx, tx, evt); err != nil {
+			return err
+		}
+
+		// channel joins are only told to the subscriber, the admins learn of the resolved
+		// requests here
+		admins, err := joinApprovers(ctx, chatTx, member.conv.ID)
+		if err != nil {
+			return err
+		}
+		approved = newEvent(domain.EventJoinApproved, member.conv.ID, admins, &JoinDecisionPayload{ActorID: userID, UserIDs: addedIDs})
+		return u.record(ctx, tx, approved)
+	})
+	if err != nil {
+		return nil, membershipError(err)
+	}
+
+	if len(added) > 0 {
+		u.publish(ctx, evt)
+		u.publish(ctx, approved)
+	}
+	return added, nil
+}
+
+// DeclineJoinRequests drops the pending requests of the users. The requesters and the
+// chat's admins are notified.
+func (u *ChatUsecase) DeclineJoinRequests(ctx context.Context, userID uint64, req JoinRequestsDecisionRequest) error {
+	member, ids, err := u.authorizeJoinDecision(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+
+	var evt *domain.Event
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		var declined []uint64
+		for _, id := range ids {
+			ok, err := chatTx.DeleteJoinRequest(ctx, member.conv.ID, id)
+			if err != nil {
+				return err
+			}
+			if ok {
+				declined = append(declined, id)
+			}
+		}
+		if len(declined) == 0 {
+			return nil
+		}
+
+		admins, err := joinApprovers(ctx, chatTx, member.conv.ID)
+		if err != nil {
+			return err
+		}
+		recipients := uniqueIDs(append(declined, admins...))
+		e := newEvent(domain.EventJoinDeclined, member.conv.ID, recipients, &JoinDecisionPayload{ActorID: userID, UserIDs: declined})
+		evt = &e
+		return u.record(ctx, tx, e)
+	})
+	if err != nil {
+		return membershipError(err)
+	}
+
+	if evt != nil {
+		u.publish(ctx, *evt)
+	}
+	return nil
+}
+
+func (u *ChatUsecase) authorizeJoinDecision(ctx context.Context, userID uint64, req JoinRequestsDecisionRequest) (*membership, []uint64, error) {
+	ids := uniqueIDs(req.UserIDs)
+	if len(ids) == 0 {
+		return nil, nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "user_ids is required")
+	}
+	if len(ids) > maxAddMembers {
+		return nil, nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "too many users")
+	}
+
+	member, err := u.authorizeGroup(ctx, req.ConversationID, userID, permApproveJoins)
+	if err != nil {
+		return nil, nil, err
+	}
+	return member, ids, nil
+}
+
+// checkJoin reports whether the user is already in the chat and refuses banned users.
+func (u *ChatUsecase) checkJoin(ctx context.Context, conversationID, userID uint64) (bool, error) {
+	part, err := u.chatStore.GetParticipant(ctx, conversationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if isActive(part) {
+		return true, nil
+	}
+	if part.Banned(time.Now()) {
+		return false, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you are banned from this chat")
+	}
+	return false, nil
+}
+
+// queueJoinRequest stores a join request and notifies the requester and the admins who
+// may approve it. It returns a nil event when the user was already waiting.
+func (u *ChatUsecase) queueJoinRequest(ctx context.Context, tx *sql.Tx, jr *domain.JoinRequest) (*domain.Event, error) {
+	chatTx := u.chatStore.WithTx(tx)
+
+	added, err := chatTx.AddJoinRequest(ctx, jr)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return nil, nil
+	}
+
+	admins, err := joinApprovers(ctx, chatTx, jr.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	evt := newEvent(domain.EventJoinRequested, jr.ConversationID, uniqueIDs(append(admins, jr.UserID)), &JoinRequestResponse{
+		ConversationID: jr.ConversationID,
+		UserID:         jr.UserID,
+		RequestedAt:    jr.RequestedAt,
+		Message:        jr.Message,
+	})
+	if err := u.record(ctx, tx, evt); err != nil {
+		return nil, err
+	}
+	return &evt, nil
+}
+
+// joinApprovers returns the members who may approve join requests. Only the roles
+// holding the permission are loaded, never the whole member list.
+func joinApprovers(ctx context.Context, chatTx chatRepo.ChatStore, conversationID uint64) ([]uint64, error) {
+	var roles []domain.ParticipantRole
+	for role, perms := range rolePermissions {
+		if perms&permApproveJoins != 0 {
+			roles = append(roles, role)
+		}
+	}
+
+	parts, err := chatTx.GetParticipantsByRole(ctx, conversationID, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, 0, len(parts))
+	for _, p := range parts {
+		ids = append(ids, p.UserID)
+	}
+	return ids, nil
+}
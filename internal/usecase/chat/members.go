@@ -0,0 +1,376 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	chatRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/chat"
+)
+
+type permission uint32
+
+const (
+	permAddMembers permission = 1 << iota
+	permRemoveMembers
+	permDeleteMessages // of other members
+	permManageAdmins
+	permTransferOwnership
+	permModerate // ban, restrict and mute members
+	permManageInvites
+	permApproveJoins
+)
+
+// rolePermissions is what each role may do in a group. Acting on another member also
+// requires outranking them, see roleRank.
+var rolePermissions = map[domain.ParticipantRole]permission{
+	domain.ParticipantRoleOwner:  permAddMembers | permRemoveMembers | permDeleteMessages | permManageAdmins | permTransferOwnership | permModerate | permManageInvites | permApproveJoins,
+	domain.ParticipantRoleAdmin:  permAddMembers | permRemoveMembers | permDeleteMessages | permModerate | permManageInvites | permApproveJoins,
+	domain.ParticipantRoleMember: permAddMembers,
+}
+
+var roleRank = map[domain.ParticipantRole]int{
+	domain.ParticipantRoleOwner:      3,
+	domain.ParticipantRoleAdmin:      2,
+	domain.ParticipantRoleMember:     1,
+	domain.ParticipantRoleRestricted: 1,
+}
+
+// can reports whether the member holds the permission. Nobody manages a DM, and channel
+// subscribers hold no permissions at all.
+func (m membership) can(p permission) bool {
+	return m.conv.Type != domain.ConversationTypeDM && !m.subscriber() && rolePermissions[m.part.Role]&p != 0
+}
+
+// subscriber reports whether the member only reads the channel; only its owner and
+// admins post.
+func (m membership) subscriber() bool {
+	return m.conv.Type == domain.ConversationTypeChannel && roleRank[m.part.Role] < roleRank[domain.ParticipantRoleAdmin]
+}
+
+// outranks reports whether the member's role is above the target's.
+func (m membership) outranks(target *domain.Participant) bool {
+	return roleRank[m.part.Role] > roleRank[target.Role]
+}
+
+// authorizeGroup authorizes the user in a group and checks the permission, if any.
+func (u *ChatUsecase) authorizeGroup(ctx context.Context, conversationID, userID uint64, p permission) (*membership, error) {
+	member, err := u.authorize(ctx, conversationID, userID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+	if member.conv.Type == domain.ConversationTypeDM {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "members of a direct chat cannot be managed")
+	}
+	if p != 0 && !member.can(p) {
+		return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you are not allowed to do this in this chat")
+	}
+	return member, nil
+}
+
+// activeMember returns the target's participation if they are currently in the chat.
+func (u *ChatUsecase) activeMember(ctx context.Context, conversationID, userID uint64) (*domain.Participant, error) {
+	notFound := apperr.New(apperr.CodeNotFound, http.StatusNotFound, "member not found")
+
+	part, err := u.chatStore.GetParticipant(ctx, conversationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, notFound
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if !isActive(part) {
+		return nil, notFound
+	}
+	return part, nil
+}
+
+// isActive reports whether the participant is currently in the chat.
+func isActive(p *domain.Participant) bool {
+	return p.LeftAt == nil && p.Role != domain.ParticipantRoleLeft && p.Role != domain.ParticipantRoleBanned
+}
+
+// GetMembers pages through the active members of a group or channel, owner and
+// admins first, then by join time.
+func (u *ChatUsecase) GetMembers(ctx context.Context, userID uint64, req GetMembersRequest) (*MembersResponse, error) {
+	member, err := u.authorizeGroup(ctx, req.ConversationID, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if member.subscriber() {
+		return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "only admins can see the subscribers of a channel")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultMembersLimit
+	}
+	limit = min(limit, maxMembersLimit)
+
+	parts, err := u.chatStore.GetMembersPage(ctx, req.ConversationID, req.AfterID, limit)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := &MembersResponse{Members: make([]MemberResponse, 0, len(parts))}
+	for _, p := range parts {
+		resp.Members = append(resp.Members, toMemberResponse(p))
+	}
+	if len(parts) == limit {
+		next := parts[len(parts)-1].UserID
+		resp.NextCursor = &next
+	}
+	return resp, nil
+}
+
+// AddMembers adds users to a group. Users who are already members are skipped; banned
+// users cannot be added back until their ban is lifted or expires.
+func (u *ChatUsecase) AddMembers(ctx context.Context, userID uint64, req AddMembersRequest) ([]MemberResponse, error) {
+	ids := uniqueIDs(req.UserIDs)
+	if len(ids) == 0 {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "user_ids is required")
+	}
+	if len(ids) > maxAddMembers {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "too many users")
+	}
+
+	member, err := u.authorizeGroup(ctx, req.ConversationID, userID, permAddMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := u.chatStore.ExistingUserIDs(ctx, ids)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if len(existing) != len(ids) {
+		return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "user not found")
+	}
+
+	var (
+		added []MemberResponse
+		evt   domain.Event
+	)
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		var addedIDs []uint64
+		for _, id := range ids {
+			part, err := chatTx.GetParticipant(ctx, member.conv.ID, id)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if part != nil {
+				if part.Banned(time.Now()) {
+					return apperr.New(apperr.CodeForbidden, http.StatusForbidden, "user is banned from this chat")
+				}
+				if part.LeftAt == nil && part.Role != domain.ParticipantRoleLeft {
+					continue // already a member
+				}
+			}
+
+			newPart := domain.Participant{ConversationID: member.conv.ID, UserID: id, Role: domain.ParticipantRoleMember}
+			if err := chatTx.AddParticipant(ctx, &newPart); err != nil {
+				return err
+			}
+			added = append(added, toMemberResponse(newPart))
+			addedIDs = append(addedIDs, id)
+		}
+		if len(addedIDs) == 0 {
+			return nil
+		}
+
+		evt = joinedEvent(member.conv, addedIDs, &MembersChangedPayload{ActorID: userID, Members: added})
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		return nil, membershipError(err)
+	}
+
+	if len(added) > 0 {
+		u.publish(ctx, evt)
+	}
+	return added, nil
+}
+
+// RemoveMember removes a member from a group. Only members of a lower role can be removed.
+func (u *ChatUsecase) RemoveMember(ctx context.Context, userID uint64, req MemberRequest) error {
+	if req.UserID == userID {
+		return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "use leave to leave a chat")
+	}
+
+	member, err := u.authorizeGroup(ctx, req.ConversationID, userID, permRemoveMembers)
+	if err != nil {
+		return err
+	}
+	target, err := u.activeMember(ctx, req.ConversationID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if !member.outranks(target) {
+		return apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you cannot remove this member")
+	}
+
+	return u.removeParticipant(ctx, member.conv, userID, target.UserID)
+}
+
+// LeaveChat removes the caller from a group. The owner must hand the group over first
+// unless nobody else is left in it.
+func (u *ChatUsecase) LeaveChat(ctx context.Context, userID, conversationID uint64) error {
+	member, err := u.authorizeGroup(ctx, conversationID, userID, 0)
+	if err != nil {
+		return err
+	}
+
+	if member.part.Role == domain.ParticipantRoleOwner {
+		others, err := u.chatStore.HasOtherMembers(ctx, conversationID, userID)
+		if err != nil {
+			return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+		}
+		if others {
+			return apperr.New(apperr.CodeConflict, http.StatusConflict, "transfer ownership before leaving the chat")
+		}
+	}
+
+	return u.removeParticipant(ctx, member.conv, userID, userID)
+}
+
+func (u *ChatUsecase) removeParticipant(ctx context.Context, conv *domain.Conversation, actorID, userID uint64) error {
+	var evt domain.Event
+	err := u.uow.Do(ctx, func(tx *sql.Tx) error {
+		if err := u.chatStore.WithTx(tx).RemoveParticipant(ctx, conv.ID, userID); err != nil {
+			return err
+		}
+		evt = leftEvent(conv, userID, &MemberRemovedPayload{ActorID: actorID, UserID: userID})
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to remove member", err)
+	}
+
+	u.publish(ctx, evt)
+	return nil
+}
+
+// SetMemberRole promotes a member to admin or demotes an admin to member.
+func (u *ChatUsecase) SetMemberRole(ctx context.Context, userID uint64, req SetMemberRoleRequest) (*MemberResponse, error) {
+	if req.Role != domain.ParticipantRoleAdmin && req.Role != domain.ParticipantRoleMember {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "role must be admin or member")
+	}
+
+	member, err := u.authorizeGroup(ctx, req.ConversationID, userID, permManageAdmins)
+	if err != nil {
+		return nil, err
+	}
+	target, err := u.activeMember(ctx, req.ConversationID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.outranks(target) {
+		return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you cannot change the role of this member")
+	}
+	if target.Role != domain.ParticipantRoleAdmin && target.Role != domain.ParticipantRoleMember {
+		return nil, apperr.New(apperr.CodeConflict, http.StatusConflict, "only members and admins can be promoted or demoted")
+	}
+
+	from := target.Role
+	target.Role = req.Role
+	resp := toMemberResponse(*target)
+	if from == req.Role {
+		return &resp, nil
+	}
+
+	err = u.changeRoles(ctx, member.conv.ID, userID, []roleChange{{target.UserID, from, req.Role}}, []MemberResponse{resp})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// TransferOwnership makes another member the owner of the group; the previous owner
+// stays on as an admin.
+func (u *ChatUsecase) TransferOwnership(ctx context.Context, userID uint64, req MemberRequest) error {
+	if req.UserID == userID {
+		return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "you already own this chat")
+	}
+
+	member, err := u.authorizeGroup(ctx, req.ConversationID, userID, permTransferOwnership)
+	if err != nil {
+		return err
+	}
+	target, err := u.activeMember(ctx, req.ConversationID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if target.Role != domain.ParticipantRoleAdmin && target.Role != domain.ParticipantRoleMember {
+		return apperr.New(apperr.CodeConflict, http.StatusConflict, "ownership can only be transferred to a member or an admin")
+	}
+
+	newOwner, oldOwner := *target, *member.part
+	newOwner.Role, oldOwner.Role = domain.ParticipantRoleOwner, domain.ParticipantRoleAdmin
+
+	return u.changeRoles(ctx, member.conv.ID, userID, []roleChange{
+		{userID, domain.ParticipantRoleOwner, domain.ParticipantRoleAdmin},
+		{target.UserID, target.Role, domain.ParticipantRoleOwner},
+	}, []MemberResponse{toMemberResponse(newOwner), toMemberResponse(oldOwner)})
+}
+
+type roleChange struct {
+	userID   uint64
+	from, to domain.ParticipantRole
+}
+
+// changeRoles applies the changes atomically. A member whose role changed in the
+// meantime aborts all of them with a conflict.
+func (u *ChatUsecase) changeRoles(ctx context.Context, conversationID, actorID uint64, changes []roleChange, members []MemberResponse) error {
+	var evt domain.Event
+	err := u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		for _, c := range changes {
+			ok, err := chatTx.UpdateParticipantRole(ctx, conversationID, c.userID, c.from, c.to)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return apperr.New(apperr.CodeConflict, http.StatusConflict, "the member's role has changed, try again")
+			}
+		}
+
+		evt = newEvent(domain.EventParticipantUpdated, conversationID, nil, &MembersChangedPayload{ActorID: actorID, Members: members})
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		return membershipError(err)
+	}
+
+	u.publish(ctx, evt)
+	return nil
+}
+
+// membershipError keeps the application errors raised inside a membership transaction.
+func membershipError(err error) error {
+	var ae *apperr.AppError
+	if errors.As(err, &ae) {
+		return err
+	}
+	if errors.Is(err, chatRepo.ErrParticipantBanned) {
+		return apperr.New(apperr.CodeForbidden, http.StatusForbidden, "user is banned from this chat")
+	}
+	return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to update members", err)
+}
+
+func toMemberResponse(p domain.Participant) MemberResponse {
+	return MemberResponse{
+		UserID:          p.UserID,
+		Role:            p.Role,
+		JoinedAt:        p.JoinedAt,
+		MutedUntil:      p.MutedUntil,
+		Restrictions:    p.Restrictions,
+		RestrictedUntil: p.RestrictedUntil,
+	}
+}
@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
+
+func testMember(typ domain.ConversationType, role domain.ParticipantRole) membership {
+	return membership{
+		conv: &domain.Conversation{Type: typ},
+		part: &domain.Participant{Role: role},
+	}
+}
+
+func TestMembershipCan(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  domain.ConversationType
+		role domain.ParticipantRole
+		perm permission
+		want bool
+	}{
+		{"owner transfers ownership", domain.ConversationTypeGroup, domain.ParticipantRoleOwner, permTransferOwnership, true},
+		{"owner manages admins", domain.ConversationTypeGroup, domain.ParticipantRoleOwner, permManageAdmins, true},
+		{"admin cannot manage admins", domain.ConversationTypeGroup, domain.ParticipantRoleAdmin, permManageAdmins, false},
+		{"admin cannot transfer ownership", domain.ConversationTypeGroup, domain.ParticipantRoleAdmin, permTransferOwnership, false},
+		{"admin moderates", domain.ConversationTypeGroup, domain.ParticipantRoleAdmin, permModerate, true},
+		{"admin approves joins", domain.ConversationTypeGroup, domain.ParticipantRoleAdmin, permApproveJoins, true},
+		{"member adds members", domain.ConversationTypeGroup, domain.ParticipantRoleMember, permAddMembers, true},
+		{"member cannot remove members", domain.ConversationTypeGroup, domain.ParticipantRoleMember, permRemoveMembers, false},
+		{"member cannot manage invites", domain.ConversationTypeGroup, domain.ParticipantRoleMember, permManageInvites, false},
+		{"restricted member holds nothing", domain.ConversationTypeGroup, domain.ParticipantRoleRestricted, permAddMembers, false},
+		{"banned user holds nothing", domain.ConversationTypeGroup, domain.ParticipantRoleBanned, permAddMembers, false},
+		{"nobody manages a dm", domain.ConversationTypeDM, domain.ParticipantRoleOwner, permAddMembers, false},
+		{"channel admin moderates", domain.ConversationTypeChannel, domain.ParticipantRoleAdmin, permModerate, true},
+		{"channel subscriber cannot add members", domain.ConversationTypeChannel, domain.ParticipantRoleMember, permAddMembers, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testMember(tt.typ, tt.role).can(tt.perm); got != tt.want {
+				t.Errorf("can() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMembershipOutranks(t *testing.T) {
+	tests := []struct {
+		actor  domain.ParticipantRole
+		target domain.ParticipantRole
+		want   bool
+	}{
+		{domain.ParticipantRoleOwner, domain.ParticipantRoleAdmin, true},
+		{domain.ParticipantRoleOwner, domain.ParticipantRoleMember, true},
+		{domain.ParticipantRoleAdmin, domain.ParticipantRoleMember, true},
+		{domain.ParticipantRoleAdmin, domain.ParticipantRoleRestricted, true},
+		{domain.ParticipantRoleAdmin, domain.ParticipantRoleAdmin, false},
+		{domain.ParticipantRoleAdmin, domain.ParticipantRoleOwner, false},
+		{domain.ParticipantRoleMember, domain.ParticipantRoleRestricted, false},
+		{domain.ParticipantRoleMember, domain.ParticipantRoleMember, false},
+		{domain.ParticipantRoleOwner, domain.ParticipantRoleOwner, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.actor)+" over "+string(tt.target), func(t *testing.T) {
+			m := testMember(domain.ConversationTypeGroup, tt.actor)
+			if got := m.outranks(&domain.Participant{Role: tt.target}); got != tt.want {
+				t.Errorf("outranks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
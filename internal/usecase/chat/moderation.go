@@ -0,0 +1,352 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	chatRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/chat"
+)
+
+// BanMember removes a user from a group and keeps them out until the ban expires or is
+// lifted. Users who are not in the group can be banned too.
+func (u *ChatUsecase) BanMember(ctx context.Context, userID uint64, req ModerationRequest) error {
+	until, err := moderationUntil(req.Duration, true)
+	if err != nil {
+		return err
+	}
+	member, target, err := u.moderationTarget(ctx, userID, req, false)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		existing, err := u.chatStore.ExistingUserIDs(ctx, []uint64{req.UserID})
+		if err != nil {
+			return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+		}
+		if len(existing) == 0 {
+			return apperr.New(apperr.CodeNotFound, http.StatusNotFound, "user not found")
+		}
+	}
+
+	// an active member is dropped from the chat, so their clients stop receiving it
+	var removed *domain.Event
+	if target != nil && isActive(target) {
+		evt := leftEvent(member.conv, req.UserID, &MemberRemovedPayload{ActorID: userID, UserID: req.UserID})
+		removed = &evt
+	}
+
+	act := newModerationAction(member, req, domain.ModerationBan, until)
+	return u.moderate(ctx, act, func(chatTx chatRepo.ChatStore) error {
+		return chatTx.BanParticipant(ctx, act.ConversationID, act.TargetID, until)
+	}, removed)
+}
+
+// UnbanMember lifts a ban. The user is not added back, but may be again.
+func (u *ChatUsecase) UnbanMember(ctx context.Context, userID uint64, req ModerationRequest) error {
+	member, target, err := u.moderationTarget(ctx, userID, req, false)
+	if err != nil {
+		return err
+	}
+	notBanned := apperr.New(apperr.CodeConflict, http.StatusConflict, "user is not banned")
+	if target == nil || target.Role != domain.ParticipantRoleBanned {
+		return notBanned
+	}
+
+	act := newModerationAction(member, req, domain.ModerationUnban, nil)
+	return u.moderate(ctx, act, func(chatTx chatRepo.ChatStore) error {
+		ok, err := chatTx.UnbanParticipant(ctx, act.ConversationID, act.TargetID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return notBanned
+		}
+		return nil
+	}, nil)
+}
+
+// RestrictMember limits what a member may send. Restricting from messages silences
+// them entirely; media and links only reject messages carrying those.
+func (u *ChatUsecase) RestrictMember(ctx context.Context, userID uint64, req ModerationRequest) error {
+	restrictions, err := normalizeRestrictions(req.Restrictions)
+	if err != nil {
+		return err
+	}
+	until, err := moderationUntil(req.Duration, true)
+	if err != nil {
+		return err
+	}
+	member, target, err := u.moderationTarget(ctx, userID, req, true)
+	if err != nil {
+		return err
+	}
+	if target.Role != domain.ParticipantRoleMember && target.Role != domain.ParticipantRoleRestricted {
+		return apperr.New(apperr.CodeConflict, http.StatusConflict, "only members can be restricted, demote the admin first")
+	}
+
+	from := target.Role
+	target.Role, target.Restrictions, target.RestrictedUntil = domain.ParticipantRoleRestricted, restrictions, until
+
+	act := newModerationAction(member, req, domain.ModerationRestrict, until)
+	act.Restrictions = restrictions
+	return u.moderate(ctx, act, func(chatTx chatRepo.ChatStore) error {
+		ok, err := chatTx.RestrictParticipant(ctx, act.ConversationID, act.TargetID, from, restrictions, until)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return apperr.New(apperr.CodeConflict, http.StatusConflict, "the member's role has changed, try again")
+		}
+		return nil
+	}, memberUpdated(userID, target))
+}
+
+// UnrestrictMember lifts all restrictions of a member.
+func (u *ChatUsecase) UnrestrictMember(ctx context.Context, userID uint64, req ModerationRequest) error {
+	member, target, err := u.moderationTarget(ctx, userID, req, true)
+	if err != nil {
+		return err
+	}
+	if target.Role != domain.ParticipantRoleRestricted && len(target.Restrictions) == 0 {
+		return apperr.New(apperr.CodeConflict, http.StatusConflict, "member is not restricted")
+	}
+
+	if target.Role == domain.ParticipantRoleRestricted {
+		target.Role = domain.ParticipantRoleMember
+	}
+	target.Restrictions, target.RestrictedUntil = nil, nil
+
+	act := newModerationAction(member, req, domain.ModerationUnrestrict, nil)
+	return u.moderate(ctx, act, func(chatTx chatRepo.ChatStore) error {
+		return chatTx.UnrestrictParticipant(ctx, act.ConversationID, act.TargetID)
+	}, memberUpdated(userID, target))
+}
+
+// MuteMember keeps a member from sending anything for a while.
+func (u *ChatUsecase) MuteMember(ctx context.Context, userID uint64, req ModerationRequest) error {
+	until, err := moderationUntil(req.Duration, false)
+	if err != nil {
+		return err
+	}
+	member, target, err := u.moderationTarget(ctx, userID, req, true)
+	if err != nil {
+		return err
+	}
+	target.MutedUntil = until
+
+	act := newModerationAction(member, req, domain.ModerationMute, until)
+	return u.moderate(ctx, act, func(chatTx chatRepo.ChatStore) error {
+		return chatTx.SetMutedUntil(ctx, act.ConversationID, act.TargetID, until)
+	}, memberUpdated(userID, target))
+}
+
+func (u *ChatUsecase) UnmuteMember(ctx context.Context, userID uint64, req ModerationRequest) error {
+	member, target, err := u.moderationTarget(ctx, userID, req, true)
+	if err != nil {
+		return err
+	}
+	if !target.Muted(time.Now()) {
+		return apperr.New(apperr.CodeConflict, http.StatusConflict, "member is not muted")
+	}
+	target.MutedUntil = nil
+
+	act := newModerationAction(member, req, domain.ModerationUnmute, nil)
+	return u.moderate(ctx, act, func(chatTx chatRepo.ChatStore) error {
+		return chatTx.SetMutedUntil(ctx, act.ConversationID, act.TargetID, nil)
+	}, memberUpdated(userID, target))
+}
+
+// GetModerationLog pages through the moderation actions of a group, newest first.
+// Only moderators may read it.
+func (u *ChatUsecase) GetModerationLog(ctx context.Context, userID uint64, req GetModerationLogRequest) (*ModerationLogResponse, error) {
+	if _, err := u.authorizeGroup(ctx, req.ConversationID, userID, permModerate); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultModerationLogLimit
+	}
+	limit = min(limit, maxModerationLogLimit)
+
+	actions, err := u.chatStore.GetModerationActions(ctx, req.ConversationID, req.BeforeID, limit)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := &ModerationLogResponse{Actions: make([]ModerationActionResponse, 0, len(actions))}
+	for _, a := range actions {
+		resp.Actions = append(resp.Actions, toModerationActionResponse(a))
+	}
+	if len(actions) == limit {
+		next := actions[len(actions)-1].ID
+		resp.NextCursor = &next
+	}
+	return resp, nil
+}
+
+// moderationTarget authorizes a moderator acting on another user of the group. When
+// active is false the target may have left or never joined, and is nil in the latter case.
+func (u *ChatUsecase) moderationTarget(ctx context.Context, userID uint64, req ModerationRequest, active bool) (*membership, *domain.Participant, error) {
+	if req.UserID == userID {
+		return nil, nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "you cannot moderate yourself")
+	}
+	if req.Reason != nil && utf8.RuneCountInString(*req.Reason) > maxReasonLength {
+		return nil, nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "reason is too long")
+	}
+
+	member, err := u.authorizeGroup(ctx, req.ConversationID, userID, permModerate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var target *domain.Participant
+	if active {
+		if target, err = u.activeMember(ctx, req.ConversationID, req.UserID); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		target, err = u.chatStore.GetParticipant(ctx, req.ConversationID, req.UserID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+		}
+	}
+
+	if target != nil && !member.outranks(target) {
+		return nil, nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you cannot moderate this member")
+	}
+	return member, target, nil
+}
+
+// moderate applies a moderation action and, in the same transaction, logs it and
+// announces it with a system message. memberEvt, if any, reports the member's new state.
+func (u *ChatUsecase) moderate(ctx context.Context, act *domain.ModerationAction, apply func(chatRepo.ChatStore) error, memberEvt *domain.Event) error {
+	notice, err := json.Marshal(ModerationNotice{
+		Action:       act.Action,
+		ActorID:      act.ActorID,
+		UserID:       act.TargetID,
+		Restrictions: act.Restrictions,
+		Until:        act.Until,
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	text := string(notice)
+	msg := domain.Message{ConversationID: act.ConversationID, Type: domain.MessageTypeSystem, Text: &text}
+
+	var evt domain.Event
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		if err := apply(chatTx); err != nil {
+			return err
+		}
+		if err := chatTx.AddModerationAction(ctx, act); err != nil {
+			return err
+		}
+		if err := chatTx.SendMessage(ctx, &msg); err != nil {
+			return err
+		}
+
+		evt = newEvent(domain.EventMessageCreated, act.ConversationID, nil, toMessageResponse(msg))
+		if err := u.record(ctx, tx, evt); err != nil {
+			return err
+		}
+		if memberEvt != nil {
+			return u.record(ctx, tx, *memberEvt)
+		}
+		return nil
+	})
+	if err != nil {
+		return membershipError(err)
+	}
+
+	u.publish(ctx, evt)
+	if memberEvt != nil {
+		u.publish(ctx, *memberEvt)
+	}
+	return nil
+}
+
+func newModerationAction(m *membership, req ModerationRequest, typ domain.ModerationActionType, until *time.Time) *domain.ModerationAction {
+	return &domain.ModerationAction{
+		ConversationID: m.conv.ID,
+		ActorID:        m.part.UserID,
+		TargetID:       req.UserID,
+		Action:         typ,
+		Until:          until,
+		Reason:         req.Reason,
+	}
+}
+
+func memberUpdated(actorID uint64, target *domain.Participant) *domain.Event {
+	evt := newEvent(domain.EventParticipantUpdated, target.ConversationID, nil, &MembersChangedPayload{
+		ActorID: actorID,
+		Members: []MemberResponse{toMemberResponse(*target)},
+	})
+	return &evt
+}
+
+// moderationUntil turns a duration in seconds into the time an action expires. Zero
+// means for good where permanent is allowed.
+func moderationUntil(seconds int64, permanent bool) (*time.Time, error) {
+	if seconds < 0 || seconds > int64(maxModerationDuration/time.Second) {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "duration is out of range")
+	}
+	if seconds == 0 {
+		if permanent {
+			return nil, nil
+		}
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "duration is required")
+	}
+
+	until := time.Now().Add(time.Duration(seconds) * time.Second)
+	return &until, nil
+}
+
+// normalizeRestrictions validates and deduplicates restrictions. Restricting from
+// messages covers everything else.
+func normalizeRestrictions(rs []domain.Restriction) ([]domain.Restriction, error) {
+	if len(rs) == 0 {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "restrictions is required")
+	}
+
+	seen := make(map[domain.Restriction]struct{}, len(rs))
+	out := make([]domain.Restriction, 0, len(rs))
+	for _, r := range rs {
+		switch r {
+		case domain.RestrictionMessages, domain.RestrictionMedia, domain.RestrictionLinks:
+		default:
+			return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "unknown restriction: "+string(r))
+		}
+		if _, ok := seen[r]; ok {
+			continue
+		}
+		seen[r] = struct{}{}
+		out = append(out, r)
+	}
+	if _, ok := seen[domain.RestrictionMessages]; ok {
+		return []domain.Restriction{domain.RestrictionMessages}, nil
+	}
+	return out, nil
+}
+
+func toModerationActionResponse(a domain.ModerationAction) ModerationActionResponse {
+	return ModerationActionResponse{
+		ID:           a.ID,
+		ActorID:      a.ActorID,
+		UserID:       a.TargetID,
+		Action:       a.Action,
+		Restrictions: a.Restrictions,
+		Until:        a.Until,
+		Reason:       a.Reason,
+		CreatedAt:    a.CreatedAt,
+	}
+}
@@ -1,21 +1,99 @@
 package chat
 
 import (
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/config"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/eventbus"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/minio"
 	chatRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/chat"
+	mediaRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/media"
+	outboxRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/outbox"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/uow"
 	"github.com/rs/zerolog"
 )
 
+const (
+	// maxMessageLength is the maximum number of characters allowed in a text message.
+	maxMessageLength = 4096
+
+	// previewLength is the number of characters of the original kept in reply and forward previews.
+	previewLength = 100
+
+	maxAttachments = 10
+
+	// attachmentURLExpiry is how long the download URLs returned with messages stay valid.
+	attachmentURLExpiry = time.Hour
+
+	maxForwardMessages = 100
+	maxForwardTargets  = 20
+
+	// maxAddMembers bounds how many users one request may add to a group.
+	maxAddMembers = 100
+
+	// maxModerationDuration bounds timed bans, restrictions and mutes.
+	maxModerationDuration = 366 * 24 * time.Hour
+	maxReasonLength       = 512
+
+	defaultMembersLimit = 50
+	maxMembersLimit     = 200
+
+	defaultModerationLogLimit = 50
+	maxModerationLogLimit     = 100
+
+	// maxInviteLinkTTL and maxInviteLinkUses bound the limits of an invite link.
+	maxInviteLinkTTL  = 366 * 24 * time.Hour
+	maxInviteLinkUses = 100000
+	maxJoinMessage    = 512
+
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+
+	defaultEditWindow = 48 * time.Hour
+
+	// maxUnreadCount is the largest unread count reported exactly; beyond it conversations
+	// report maxUnreadCount+1, shown as "99+".
+	maxUnreadCount = 99
+
+	// maxReactionBytes bounds a reaction; the longest ZWJ emoji sequences are around 35 bytes.
+	maxReactionBytes = 64
+)
+
 type ChatUsecase struct {
-	chatStore chatRepo.ChatStore
-	uow       uow.UnitOfWork
-	logger    zerolog.Logger
+	chatStore  chatRepo.ChatStore
+	mediaStore mediaRepo.MediaStore
+	outbox     outboxRepo.OutboxStore
+	uow        uow.UnitOfWork
+	events     eventbus.Publisher
+	storage    minio.ObjectStorage
+	cfg        config.ChatConfig
+	logger     zerolog.Logger
+
+	allowedReactions map[string]struct{}
 }
 
-func NewChatUsecase(chatStore chatRepo.ChatStore, uow uow.UnitOfWork, logger zerolog.Logger) *ChatUsecase {
+func NewChatUsecase(chatStore chatRepo.ChatStore, mediaStore mediaRepo.MediaStore, outbox outboxRepo.OutboxStore, uow uow.UnitOfWork, events eventbus.Publisher, storage minio.ObjectStorage, cfg config.ChatConfig, logger zerolog.Logger) *ChatUsecase {
+	if cfg.EditWindow <= 0 {
+		cfg.EditWindow = defaultEditWindow
+	}
+
+	var allowed map[string]struct{}
+	if len(cfg.AllowedReactions) > 0 {
+		allowed = make(map[string]struct{}, len(cfg.AllowedReactions))
+		for _, emoji := range cfg.AllowedReactions {
+			allowed[emoji] = struct{}{}
+		}
+	}
+
 	return &ChatUsecase{
-		chatStore: chatStore,
-		uow:       uow,
-		logger:    logger,
+		chatStore:        chatStore,
+		mediaStore:       mediaStore,
+		outbox:           outbox,
+		uow:              uow,
+		events:           events,
+		storage:          storage,
+		cfg:              cfg,
+		logger:           logger,
+		allowedReactions: allowed,
 	}
 }
@@ -3,10 +3,15 @@ package chat
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	mediaRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/media"
 )
 
 func (u *ChatUsecase) StartDM(ctx context.Context, currentUserID, targetUserID uint64) (*ConversationResponse, error) {
@@ -27,7 +32,11 @@ func (u *ChatUsecase) StartDM(ctx context.Context, currentUserID, targetUserID u
 		}, nil
 	}
 
-	var conv domain.Conversation
+	var (
+		conv domain.Conversation
+		resp *ConversationResponse
+		evt  domain.Event
+	)
 	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
 		chatTx := u.chatStore.WithTx(tx)
 
@@ -52,22 +61,30 @@ func (u *ChatUsecase) StartDM(ctx context.Context, currentUserID, targetUserID u
 			return err
 		}
 
-		return nil
+		resp = &ConversationResponse{
+			ID:        conv.ID,
+			Type:      conv.Type,
+			UpdatedAt: conv.UpdatedAt,
+		}
+		evt = newEvent(domain.EventParticipantAdded, conv.ID, []uint64{currentUserID, targetUserID}, resp)
+		return u.record(ctx, tx, evt)
 	})
 
 	if err != nil {
 		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to start DM", err)
 	}
 
-	return &ConversationResponse{
-		ID:        conv.ID,
-		Type:      conv.Type,
-		UpdatedAt: conv.UpdatedAt,
-	}, nil
+	u.publish(ctx, evt)
+
+	return resp, nil
 }
 
 func (u *ChatUsecase) CreateGroup(ctx context.Context, currentUserID uint64, req CreateGroupRequest) (*ConversationResponse, error) {
-	var conv domain.Conversation
+	var (
+		conv domain.Conversation
+		resp *ConversationResponse
+		evt  domain.Event
+	)
 	err := u.uow.Do(ctx, func(tx *sql.Tx) error {
 		chatTx := u.chatStore.WithTx(tx)
 
@@ -88,6 +105,7 @@ func (u *ChatUsecase) CreateGroup(ctx context.Context, currentUserID uint64, req
 		}
 
 		// Add other participants
+		members := []uint64{currentUserID}
 		for _, userID := range req.UserIDs {
 			if userID == currentUserID {
 				continue
@@ -95,60 +113,857 @@ func (u *ChatUsecase) CreateGroup(ctx context.Context, currentUserID uint64, req
 			if err := chatTx.AddParticipant(ctx, &domain.Participant{ConversationID: conv.ID, UserID: userID, Role: domain.ParticipantRoleMember}); err != nil {
 				return err
 			}
+			members = append(members, userID)
 		}
 
-		return nil
+		resp = &ConversationResponse{
+			ID:        conv.ID,
+			Type:      conv.Type,
+			Title:     conv.Title,
+			UpdatedAt: conv.UpdatedAt,
+		}
+		evt = newEvent(domain.EventParticipantAdded, conv.ID, members, resp)
+		return u.record(ctx, tx, evt)
 	})
 
 	if err != nil {
 		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to create group", err)
 	}
 
-	return &ConversationResponse{
-		ID:        conv.ID,
-		Type:      conv.Type,
-		Title:     conv.Title,
-		UpdatedAt: conv.UpdatedAt,
-	}, nil
+	u.publish(ctx, evt)
+
+	return resp, nil
+}
+
+func (u *ChatUsecase) SendMessage(ctx context.Context, userID uint64, req SendMessageRequest) (*MessageResponse, error) {
+	typ := req.Type
+	if typ == "" {
+		typ = domain.MessageTypeText
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if err := validateContent(typ, text, req.Attachments); err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(text) > maxMessageLength {
+		return nil, apperr.New(apperr.CodeMsgTooLong, http.StatusBadRequest, "message is too long")
+	}
+
+	member, err := u.authorize(ctx, req.ConversationID, userID, actionWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := member.checkContent(typ, text); err != nil {
+		return nil, err
+	}
+
+	atts, err := u.resolveAttachments(ctx, userID, typ, req.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	// a reply must quote a message of the same conversation the sender can see
+	var replyTo *domain.Message
+	if req.ReplyToID != nil {
+		replyTo, err = u.visibleMessage(ctx, member, *req.ReplyToID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	msg := domain.Message{
+		ConversationID: req.ConversationID,
+		SenderID:       &userID,
+		Type:           typ,
+		ReplyToID:      req.ReplyToID,
+	}
+	if text != "" {
+		msg.Text = &text
+	}
+
+	var (
+		resp *MessageResponse
+		evt  domain.Event
+	)
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		if err := chatTx.SendMessage(ctx, &msg); err != nil {
+			return err
+		}
+		for i := range atts {
+			atts[i].MessageID = msg.ID
+			if err := chatTx.AddAttachment(ctx, &atts[i]); err != nil {
+				return err
+			}
+		}
+		if err := u.mediaStore.WithTx(tx).AddRefs(ctx, storageKeys(atts), 1); err != nil {
+			return err
+		}
+
+		resp = toMessageResponse(msg)
+		if resp.Attachments, err = u.toAttachmentResponses(ctx, atts, domain.VariantOriginal); err != nil {
+			return err
+		}
+		if replyTo != nil {
+			resp.ReplyTo = toPreview(*replyTo)
+		}
+		evt = newEvent(domain.EventMessageCreated, msg.ConversationID, nil, resp)
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		if errors.Is(err, mediaRepo.ErrMediaNotFound) {
+			return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "attachment not found")
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to send message", err)
+	}
+
+	u.publish(ctx, evt)
+
+	return resp, nil
 }
 
-func (u *ChatUsecase) GetMessages(ctx context.Context, userID, conversationID uint64, limit, offset int) ([]MessageResponse, error) {
-	// Optional: Check if user is participant
-	
-	messages, err := u.chatStore.GetMessages(ctx, conversationID, limit, offset)
+// ForwardMessages copies messages the user can see into conversations they can post in.
+// A forwarded copy always points at the first original, so forwarding a forward keeps
+// crediting the original sender.
+func (u *ChatUsecase) ForwardMessages(ctx context.Context, userID uint64, req ForwardMessagesRequest) ([]MessageResponse, error) {
+	msgIDs, convIDs := uniqueIDs(req.MessageIDs), uniqueIDs(req.ConversationIDs)
+	if len(msgIDs) == 0 || len(convIDs) == 0 {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "message_ids and conversation_ids are required")
+	}
+	if len(msgIDs) > maxForwardMessages || len(convIDs) > maxForwardTargets {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "too many messages or conversations to forward")
+	}
+
+	originals, err := u.chatStore.GetMessagesByIDs(ctx, msgIDs)
 	if err != nil {
 		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
 	}
+	if len(originals) != len(msgIDs) {
+		return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "message not found")
+	}
 
-	resp := make([]MessageResponse, 0, len(messages))
-	for _, m := range messages {
-		resp = append(resp, MessageResponse{
-			ID:             m.ID,
-			ConversationID: m.ConversationID,
-			SenderID:       m.SenderID,
-			Type:           m.Type,
-			Text:           m.Text,
-			CreatedAt:      m.CreatedAt,
+	sources := make(map[uint64]*membership)
+	for i := range originals {
+		orig := &originals[i]
+		member, ok := sources[orig.ConversationID]
+		if !ok {
+			if member, err = u.authorize(ctx, orig.ConversationID, userID, actionRead); err != nil {
+				return nil, err
+			}
+			sources[orig.ConversationID] = member
+		}
+		if err := u.checkVisible(ctx, member, orig); err != nil {
+			return nil, err
+		}
+		if orig.Type == domain.MessageTypeSystem {
+			return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "system messages cannot be forwarded")
+		}
+	}
+
+	for _, convID := range convIDs {
+		member, err := u.authorize(ctx, convID, userID, actionWrite)
+		if err != nil {
+			return nil, err
+		}
+		for _, orig := range originals {
+			var text string
+			if orig.Text != nil {
+				text = *orig.Text
+			}
+			if err := member.checkContent(orig.Type, text); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// copies share the stored objects of the originals
+	origAtts, err := u.chatStore.GetAttachments(ctx, msgIDs)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := make([]MessageResponse, 0, len(convIDs)*len(originals))
+	events := make([]domain.Event, 0, cap(resp))
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+		mediaTx := u.mediaStore.WithTx(tx)
+
+		for _, convID := range convIDs {
+			for _, orig := range originals {
+				root, rootSender := forwardRoot(orig)
+				msg := domain.Message{
+					ConversationID:  convID,
+					SenderID:        &userID,
+					Type:            orig.Type,
+					Text:            orig.Text,
+					ForwardFromID:   &root,
+					ForwardSenderID: rootSender,
+				}
+				if err := chatTx.SendMessage(ctx, &msg); err != nil {
+					return err
+				}
+
+				atts := make([]domain.Attachment, 0, len(origAtts[orig.ID]))
+				for _, a := range origAtts[orig.ID] {
+					a.MessageID = msg.ID
+					if err := chatTx.AddAttachment(ctx, &a); err != nil {
+						return err
+					}
+					atts = append(atts, a)
+				}
+				if err := mediaTx.AddRefs(ctx, storageKeys(atts), 1); err != nil {
+					return err
+				}
+
+				m := toMessageResponse(msg)
+				var err error
+				if m.Attachments, err = u.toAttachmentResponses(ctx, atts, domain.VariantOriginal); err != nil {
+					return err
+				}
+				resp = append(resp, *m)
+
+				evt := newEvent(domain.EventMessageCreated, convID, nil, m)
+				if err := u.record(ctx, tx, evt); err != nil {
+					return err
+				}
+				events = append(events, evt)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, mediaRepo.ErrMediaNotFound) {
+			return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "attachment not found")
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to forward messages", err)
+	}
+
+	for _, evt := range events {
+		u.publish(ctx, evt)
+	}
+
+	return resp, nil
+}
+
+func (u *ChatUsecase) EditMessage(ctx context.Context, userID uint64, req EditMessageRequest) (*MessageResponse, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "message text is required")
+	}
+	if utf8.RuneCountInString(text) > maxMessageLength {
+		return nil, apperr.New(apperr.CodeMsgTooLong, http.StatusBadRequest, "message is too long")
+	}
+
+	msg, member, err := u.authorizeMessage(ctx, req.MessageID, userID, actionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.SenderID == nil || *msg.SenderID != userID {
+		return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you can only edit your own messages")
+	}
+	if msg.Type != domain.MessageTypeText {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "only text messages can be edited")
+	}
+	// a forward quotes someone else's words
+	if msg.ForwardFromID != nil {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "forwarded messages cannot be edited")
+	}
+	if time.Since(msg.CreatedAt) > u.cfg.EditWindow {
+		return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "message can no longer be edited")
+	}
+	if err := member.checkContent(msg.Type, text); err != nil {
+		return nil, err
+	}
+
+	if msg.Text != nil && *msg.Text == text {
+		return toMessageResponse(*msg), nil
+	}
+
+	var (
+		resp *MessageResponse
+		evt  *domain.Event
+	)
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		// re-read under a lock, so concurrent edits each record the text they replaced
+		cur, err := chatTx.GetMessageForUpdate(ctx, msg.ID)
+		if err != nil {
+			return err
+		}
+		if cur.DeletedAt != nil {
+			return sql.ErrNoRows
+		}
+		if cur.Text != nil && *cur.Text == text {
+			resp = toMessageResponse(*cur)
+			return nil
+		}
+
+		if err := chatTx.AddMessageEdit(ctx, &domain.MessageEdit{MessageID: cur.ID, EditorID: userID, OldText: cur.Text}); err != nil {
+			return err
+		}
+
+		cur.Text = &text
+		if err := chatTx.UpdateMessage(ctx, cur); err != nil {
+			return err
+		}
+
+		resp = toMessageResponse(*cur)
+		updated := newEvent(domain.EventMessageUpdated, cur.ConversationID, nil, resp)
+		evt = &updated
+		return u.record(ctx, tx, updated)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "message not found")
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to edit message", err)
+	}
+
+	if evt != nil {
+		u.publish(ctx, *evt)
+	}
+
+	return resp, nil
+}
+
+// DeleteMessage deletes a message for everyone or just for the caller. Senders may delete
+// their own messages for everyone; in groups and channels admins may delete anyone's.
+func (u *ChatUsecase) DeleteMessage(ctx context.Context, userID uint64, req DeleteMessageRequest) error {
+	msg, member, err := u.authorizeMessage(ctx, req.MessageID, userID, actionRead)
+	if err != nil {
+		return err
+	}
+
+	if req.ForEveryone {
+		isSender := msg.SenderID != nil && *msg.SenderID == userID
+		if !isSender && !member.can(permDeleteMessages) {
+			return apperr.New(apperr.CodeForbidden, http.StatusForbidden, "you are not allowed to delete this message")
+		}
+	}
+
+	payload := &DeletedMessageResponse{ID: msg.ID, ConversationID: msg.ConversationID}
+
+	var evt domain.Event
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		chatTx := u.chatStore.WithTx(tx)
+
+		if req.ForEveryone {
+			if err := chatTx.DeleteMessage(ctx, msg.ID); err != nil {
+				return err
+			}
+			// nobody can see the attachments any more, let the uploader reclaim them
+			keys, err := chatTx.DeleteAttachments(ctx, msg.ID)
+			if err != nil {
+				return err
+			}
+			if err := u.mediaStore.WithTx(tx).AddRefs(ctx, keys, -1); err != nil {
+				return err
+			}
+			evt = newEvent(domain.EventMessageDeleted, msg.ConversationID, nil, payload)
+		} else {
+			if err := chatTx.HideMessage(ctx, msg.ID, userID); err != nil {
+				return err
+			}
+			evt = newEvent(domain.EventMessageHidden, msg.ConversationID, []uint64{userID}, payload)
+		}
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to delete message", err)
+	}
+
+	u.publish(ctx, evt)
+
+	return nil
+}
+
+// GetMessageEdits returns the revision history of a message, oldest first.
+func (u *ChatUsecase) GetMessageEdits(ctx context.Context, userID, messageID uint64) ([]MessageEditResponse, error) {
+	if _, _, err := u.authorizeMessage(ctx, messageID, userID, actionRead); err != nil {
+		return nil, err
+	}
+
+	edits, err := u.chatStore.GetMessageEdits(ctx, messageID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := make([]MessageEditResponse, 0, len(edits))
+	for _, e := range edits {
+		resp = append(resp, MessageEditResponse{
+			ID:       e.ID,
+			EditorID: e.EditorID,
+			OldText:  e.OldText,
+			EditedAt: e.EditedAt,
 		})
 	}
 	return resp, nil
 }
 
-func (u *ChatUsecase) GetConversations(ctx context.Context, userID uint64) ([]ConversationResponse, error) {
+func (u *ChatUsecase) GetMessages(ctx context.Context, userID uint64, req GetMessagesRequest) (*MessageHistoryResponse, error) {
+	member, err := u.authorize(ctx, req.ConversationID, userID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	cursors := 0
+	for _, c := range []uint64{req.BeforeID, req.AfterID, req.AroundID} {
+		if c != 0 {
+			cursors++
+		}
+	}
+	if cursors > 1 {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "only one of before_id, after_id and around_id can be set")
+	}
+	if req.Variant == "" {
+		req.Variant = domain.VariantOriginal
+	}
+	if !req.Variant.Valid() {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid variant")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	var resp *MessageHistoryResponse
+	switch {
+	case req.AfterID != 0:
+		resp, err = u.messagesAfter(ctx, member, req.AfterID, limit)
+	case req.AroundID != 0:
+		resp, err = u.messagesAround(ctx, member, req.AroundID, limit)
+	default:
+		resp, err = u.messagesBefore(ctx, member, req.BeforeID, limit)
+	}
+	if err != nil {
+		var ae *apperr.AppError
+		if errors.As(err, &ae) {
+			return nil, err
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	if err := u.attachReactions(ctx, userID, resp.Messages); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if err := u.attachPreviews(ctx, member, resp.Messages); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if err := u.attachAttachments(ctx, resp.Messages, req.Variant); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	return resp, nil
+}
+
+// attachPreviews quotes the originals of replies with a single query. Originals the
+// member cannot see, because they predate the member's history or were deleted by them
+// "for me", are left out.
+func (u *ChatUsecase) attachPreviews(ctx context.Context, member *membership, msgs []MessageResponse) error {
+	var ids []uint64
+	for _, m := range msgs {
+		if m.ReplyToID != nil {
+			ids = append(ids, *m.ReplyToID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	ids = uniqueIDs(ids)
+
+	originals, err := u.chatStore.GetMessagesByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	hidden, err := u.chatStore.GetHiddenMessageIDs(ctx, member.part.UserID, ids)
+	if err != nil {
+		return err
+	}
+
+	previews := make(map[uint64]*MessagePreview, len(originals))
+	for _, o := range originals {
+		if o.ConversationID != member.conv.ID || o.CreatedAt.Before(member.historyFrom()) || hidden[o.ID] {
+			continue
+		}
+		previews[o.ID] = toPreview(o)
+	}
+	for i := range msgs {
+		if id := msgs[i].ReplyToID; id != nil {
+			msgs[i].ReplyTo = previews[*id]
+		}
+	}
+	return nil
+}
+
+func (u *ChatUsecase) attachReactions(ctx context.Context, userID uint64, msgs []MessageResponse) error {
+	ids := make([]uint64, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+
+	counts, err := u.chatStore.GetReactionCounts(ctx, ids, userID)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		msgs[i].Reactions = counts[msgs[i].ID]
+	}
+	return nil
+}
+
+func (u *ChatUsecase) messagesBefore(ctx context.Context, m *membership, beforeID uint64, limit int) (*MessageHistoryResponse, error) {
+	older, err := u.chatStore.GetMessagesBefore(ctx, m.conv.ID, m.part.UserID, beforeID, m.historyFrom(), limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	hasOlder := len(older) > limit
+	if hasOlder {
+		older = older[:limit]
+	}
+
+	resp := &MessageHistoryResponse{Messages: toMessageResponses(older)}
+	if len(older) > 0 {
+		if hasOlder {
+			resp.NextCursor = &older[len(older)-1].ID
+		}
+		// the cursor message itself is newer than this page
+		if beforeID != 0 {
+			resp.PrevCursor = &older[0].ID
+		}
+	}
+	return resp, nil
+}
+
+func (u *ChatUsecase) messagesAfter(ctx context.Context, m *membership, afterID uint64, limit int) (*MessageHistoryResponse, error) {
+	newer, err := u.chatStore.GetMessagesAfter(ctx, m.conv.ID, m.part.UserID, afterID, m.historyFrom(), limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	hasNewer := len(newer) > limit
+	if hasNewer {
+		newer = newer[:limit]
+	}
+	reverseMessages(newer)
+
+	resp := &MessageHistoryResponse{Messages: toMessageResponses(newer)}
+	if len(newer) > 0 {
+		if hasNewer {
+			resp.PrevCursor = &newer[0].ID
+		}
+		// the cursor message itself is older than this page
+		resp.NextCursor = &newer[len(newer)-1].ID
+	}
+	return resp, nil
+}
+
+// messagesAround returns the target message with context on both sides, used to jump
+// to a message from search results or a reply.
+func (u *ChatUsecase) messagesAround(ctx context.Context, m *membership, aroundID uint64, limit int) (*MessageHistoryResponse, error) {
+	target, err := u.visibleMessage(ctx, m, aroundID)
+	if err != nil {
+		return nil, err
+	}
+
+	olderLimit := (limit - 1) / 2
+	newerLimit := limit - 1 - olderLimit
+
+	older, err := u.chatStore.GetMessagesBefore(ctx, m.conv.ID, m.part.UserID, aroundID, m.historyFrom(), olderLimit+1)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := u.chatStore.GetMessagesAfter(ctx, m.conv.ID, m.part.UserID, aroundID, m.historyFrom(), newerLimit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	hasOlder := len(older) > olderLimit
+	if hasOlder {
+		older = older[:olderLimit]
+	}
+	hasNewer := len(newer) > newerLimit
+	if hasNewer {
+		newer = newer[:newerLimit]
+	}
+	reverseMessages(newer)
+
+	page := make([]domain.Message, 0, len(newer)+1+len(older))
+	page = append(page, newer...)
+	page = append(page, *target)
+	page = append(page, older...)
+
+	resp := &MessageHistoryResponse{Messages: toMessageResponses(page)}
+	if hasOlder {
+		resp.NextCursor = &page[len(page)-1].ID
+	}
+	if hasNewer {
+		resp.PrevCursor = &page[0].ID
+	}
+	return resp, nil
+}
+
+func (u *ChatUsecase) GetConversations(ctx context.Context, userID uint64) (*ConversationListResponse, error) {
 	convs, err := u.chatStore.ListConversationsByUserID(ctx, userID)
 	if err != nil {
 		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
 	}
 
-	resp := make([]ConversationResponse, 0, len(convs))
+	unread, err := u.chatStore.GetUnreadCounts(ctx, userID, maxUnreadCount+1)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := &ConversationListResponse{Conversations: make([]ConversationResponse, 0, len(convs))}
 	for _, c := range convs {
-		resp = append(resp, ConversationResponse{
+		resp.Conversations = append(resp.Conversations, ConversationResponse{
 			ID:            c.ID,
 			Type:          c.Type,
 			Title:         c.Title,
+			Username:      c.Username,
+			MemberCount:   c.MemberCount,
 			LastMessageID: c.LastMessageID,
+			UnreadCount:   unread[c.ID],
 			UpdatedAt:     c.UpdatedAt,
 		})
+		resp.TotalUnread += unread[c.ID]
+	}
+	return resp, nil
+}
+
+// ConversationIDs returns the IDs of the conversations the user participates in.
+func (u *ChatUsecase) ConversationIDs(ctx context.Context, userID uint64) ([]uint64, error) {
+	convs, err := u.chatStore.ListConversationsByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	ids := make([]uint64, 0, len(convs))
+	for _, c := range convs {
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+func (u *ChatUsecase) AddReaction(ctx context.Context, userID uint64, req ReactionRequest) error {
+	if !u.validReaction(req.Emoji) {
+		return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "reaction is not allowed")
+	}
+
+	msg, _, err := u.authorizeMessage(ctx, req.MessageID, userID, actionRead)
+	if err != nil {
+		return err
+	}
+
+	reaction := domain.Reaction{MessageID: msg.ID, UserID: userID, Emoji: req.Emoji}
+
+	var (
+		added bool
+		evt   domain.Event
+	)
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		added, err = u.chatStore.WithTx(tx).AddReaction(ctx, &reaction)
+		if err != nil || !added {
+			return err
+		}
+
+		evt = newEvent(domain.EventReactionAdded, msg.ConversationID, nil, reaction)
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to add reaction", err)
+	}
+
+	if added {
+		u.publish(ctx, evt)
+	}
+
+	return nil
+}
+
+func (u *ChatUsecase) RemoveReaction(ctx context.Context, userID uint64, req ReactionRequest) error {
+	msg, _, err := u.authorizeMessage(ctx, req.MessageID, userID, actionRead)
+	if err != nil {
+		return err
+	}
+
+	reaction := domain.Reaction{MessageID: msg.ID, UserID: userID, Emoji: req.Emoji}
+
+	var (
+		removed bool
+		evt     domain.Event
+	)
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		removed, err = u.chatStore.WithTx(tx).RemoveReaction(ctx, msg.ID, userID, req.Emoji)
+		if err != nil || !removed {
+			return err
+		}
+
+		evt = newEvent(domain.EventReactionRemoved, msg.ConversationID, nil, reaction)
+		return u.record(ctx, tx, evt)
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "failed to remove reaction", err)
+	}
+
+	if removed {
+		u.publish(ctx, evt)
+	}
+
+	return nil
+}
+
+// GetReactions lists who reacted to the message and with what, oldest first.
+func (u *ChatUsecase) GetReactions(ctx context.Context, userID, messageID uint64) ([]ReactionResponse, error) {
+	if _, _, err := u.authorizeMessage(ctx, messageID, userID, actionRead); err != nil {
+		return nil, err
+	}
+
+	reactions, err := u.chatStore.GetReactions(ctx, messageID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := make([]ReactionResponse, 0, len(reactions))
+	for _, rc := range reactions {
+		resp = append(resp, ReactionResponse{UserID: rc.UserID, Emoji: rc.Emoji, CreatedAt: rc.CreatedAt})
 	}
 	return resp, nil
 }
+
+// MarkRead moves the user's read watermark in the conversation up to the message.
+// Marking an older message than the current watermark is a no-op.
+func (u *ChatUsecase) MarkRead(ctx context.Context, userID uint64, req MarkReadRequest) error {
+	member, err := u.authorize(ctx, req.ConversationID, userID, actionRead)
+	if err != nil {
+		return err
+	}
+	if _, err := u.visibleMessage(ctx, member, req.MessageID); err != nil {
+		return err
+	}
+
+	receipt := domain.ReadReceipt{ConversationID: req.ConversationID, UserID: userID, MessageID: req.MessageID}
+	advanced, err := u.chatStore.MarkRead(ctx, &receipt)
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if !advanced {
+		return nil
+	}
+
+	// read receipts are frequent and only matter to connected clients, so they skip the
+	// outbox; a channel subscriber's would be sent to every other subscriber for nothing
+	if member.subscriber() {
+		return nil
+	}
+	u.publish(ctx, newEvent(domain.EventMessageRead, req.ConversationID, nil, receipt))
+
+	return nil
+}
+
+// GetReadBy lists who has read the message, leaving out its sender. In channels only
+// the admins may see it.
+func (u *ChatUsecase) GetReadBy(ctx context.Context, userID, messageID uint64) ([]ReadReceiptResponse, error) {
+	msg, member, err := u.authorizeMessage(ctx, messageID, userID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+	if member.subscriber() {
+		return nil, apperr.New(apperr.CodeForbidden, http.StatusForbidden, "only admins can see who read a channel post")
+	}
+
+	receipts, err := u.chatStore.GetReadReceipts(ctx, msg.ConversationID, msg.ID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := make([]ReadReceiptResponse, 0, len(receipts))
+	for _, rr := range receipts {
+		if msg.SenderID != nil && *msg.SenderID == rr.UserID {
+			continue
+		}
+		resp = append(resp, ReadReceiptResponse{UserID: rr.UserID, ReadAt: rr.ReadAt})
+	}
+	return resp, nil
+}
+
+// helpers
+func toMessageResponse(m domain.Message) *MessageResponse {
+	resp := &MessageResponse{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Type:           m.Type,
+		Text:           m.Text,
+		CreatedAt:      m.CreatedAt,
+		EditedAt:       m.EditedAt,
+		ReplyToID:      m.ReplyToID,
+		ForwardFromID:  m.ForwardFromID,
+	}
+	// a forward carries its own copy of the original, so the live original is never read
+	if m.ForwardFromID != nil {
+		resp.ForwardFrom = &MessagePreview{ID: *m.ForwardFromID, SenderID: m.ForwardSenderID, Type: m.Type, Snippet: snippet(m.Text)}
+	}
+	return resp
+}
+
+func toMessageResponses(msgs []domain.Message) []MessageResponse {
+	resp := make([]MessageResponse, 0, len(msgs))
+	for _, m := range msgs {
+		resp = append(resp, *toMessageResponse(m))
+	}
+	return resp
+}
+
+func toPreview(m domain.Message) *MessagePreview {
+	p := &MessagePreview{ID: m.ID, SenderID: m.SenderID, Type: m.Type, Deleted: m.DeletedAt != nil}
+	if !p.Deleted {
+		p.Snippet = snippet(m.Text)
+	}
+	return p
+}
+
+func snippet(text *string) *string {
+	if text == nil {
+		return nil
+	}
+	s := *text
+	if utf8.RuneCountInString(s) > previewLength {
+		s = string([]rune(s)[:previewLength]) + "…"
+	}
+	return &s
+}
+
+// forwardRoot is the message a forward of m should point at, and its sender.
+func forwardRoot(m domain.Message) (uint64, *uint64) {
+	if m.ForwardFromID != nil {
+		return *m.ForwardFromID, m.ForwardSenderID
+	}
+	return m.ID, m.SenderID
+}
+
+func uniqueIDs(ids []uint64) []uint64 {
+	seen := make(map[uint64]struct{}, len(ids))
+	out := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok || id == 0 {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
+
+func reverseMessages(msgs []domain.Message) {
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+}
@@ -1,7 +1,61 @@
 package media
 
+import (
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
 
 type UploadMediaResponse struct {
-	MediaURL string `json:"media_url"`
+	MediaURL   string `json:"media_url"`
 	ObjectName string `json:"object_name"`
-}
\ No newline at end of file
+}
+
+// CreateUploadRequest asks for a slot to upload a file straight to object storage.
+type CreateUploadRequest struct {
+	Size     int64               `json:"size" binding:"required"`
+	MimeType string              `json:"mime_type" binding:"required"`
+	Purpose  domain.MediaPurpose `json:"purpose" binding:"required"`
+}
+
+// CreateUploadResponse tells the client where to PUT the file. The request must carry
+// the declared MIME type as its Content-Type.
+type CreateUploadResponse struct {
+	UploadID  string    `json:"upload_id"`
+	ObjectKey string    `json:"object_key"`
+	UploadURL string    `json:"upload_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type CompleteUploadRequest struct {
+	UploadID string `json:"upload_id" binding:"required"`
+}
+
+// CreateResumableUploadRequest starts an upload that is sent in chunks and can be
+// resumed from the last acknowledged offset after a dropped connection.
+type CreateResumableUploadRequest struct {
+	Size     int64               `json:"size" binding:"required"`
+	MimeType string              `json:"mime_type" binding:"required"`
+	Purpose  domain.MediaPurpose `json:"purpose" binding:"required"`
+}
+
+// ResumableUploadResponse is the state of a resumable upload. Offset is where the next
+// chunk starts; Media is set once the last chunk has been received.
+type ResumableUploadResponse struct {
+	UploadID  string               `json:"upload_id"`
+	ObjectKey string               `json:"object_key"`
+	Size      int64                `json:"size"`
+	ChunkSize int64                `json:"chunk_size"`
+	Offset    int64                `json:"offset"`
+	Status    domain.UploadStatus  `json:"status"`
+	ExpiresAt time.Time            `json:"expires_at"`
+	Media     *MediaObjectResponse `json:"media,omitempty"`
+}
+
+type MediaObjectResponse struct {
+	ObjectKey string              `json:"object_key"`
+	Purpose   domain.MediaPurpose `json:"purpose"`
+	MimeType  string              `json:"mime_type"`
+	Size      int64               `json:"size"`
+	URL       string              `json:"url"`
+}
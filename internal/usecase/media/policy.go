@@ -0,0 +1,200 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/config"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+)
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// defaultPolicies apply to purposes the configuration leaves out.
+var defaultPolicies = map[domain.MediaPurpose]config.UploadPolicy{
+	domain.MediaPurposeAvatar:     {AllowedTypes: imageTypes, MaxSize: 10 << 20, MaxWidth: 8192, MaxHeight: 8192},
+	domain.MediaPurposeGroupPhoto: {AllowedTypes: imageTypes, MaxSize: 10 << 20, MaxWidth: 8192, MaxHeight: 8192},
+	domain.MediaPurposeAttachment: {AllowedTypes: []string{"*/*"}, MaxSize: maxResumableSize, MaxWidth: 16384, MaxHeight: 16384},
+}
+
+// forbiddenTypes are never accepted, whatever a policy allows: browsers would run them
+// when the presigned URL is opened. XML is rendered as a document that may carry
+// scripts, so every XML type is refused too, see forbiddenType.
+var forbiddenTypes = map[string]struct{}{
+	"text/html":                {},
+	"text/javascript":          {},
+	"application/javascript":   {},
+	"text/xml":                 {},
+	"text/xsl":                 {},
+	"application/xml":          {},
+	"application/x-msdownload": {},
+	"application/x-sh":         {},
+}
+
+var forbiddenExtensions = map[string]struct{}{
+	".exe": {}, ".dll": {}, ".com": {}, ".scr": {}, ".msi": {}, ".bat": {}, ".cmd": {},
+	".ps1": {}, ".vbs": {}, ".js": {}, ".jar": {}, ".sh": {}, ".html": {}, ".htm": {}, ".svg": {},
+	".xml": {}, ".xhtml": {}, ".xsl": {}, ".xslt": {},
+}
+
+// executableMagic are the leading bytes of native executables and scripts.
+var executableMagic = [][]byte{
+	[]byte("MZ"),               // Windows PE
+	[]byte("\x7fELF"),          // Linux ELF
+	[]byte("\xfe\xed\xfa\xce"), // Mach-O 32 bit
+	[]byte("\xfe\xed\xfa\xcf"), // Mach-O 64 bit
+	[]byte("\xce\xfa\xed\xfe"),
+	[]byte("\xcf\xfa\xed\xfe"),
+	[]byte("\xca\xfe\xba\xbe"), // Mach-O universal, Java class
+	[]byte("#!"),               // scripts
+}
+
+// policyViolations collects the reasons an upload is rejected, keyed by the offending field.
+type policyViolations map[string]string
+
+func (v policyViolations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	err := apperr.New(apperr.CodeInvalidInput, http.StatusUnprocessableEntity, "upload violates the upload policy")
+	err.Fields = v
+	return err
+}
+
+// contentInfo is what sniffing the start of a file reveals.
+type contentInfo struct {
+	mimeType   string
+	executable bool
+	width      int // images only
+	height     int
+}
+
+// inspect sniffs the content type from the first bytes of r and, for images, reads the
+// dimensions from the header. It consumes r.
+func inspect(r io.Reader) contentInfo {
+	br := bufio.NewReaderSize(r, 512)
+	head, _ := br.Peek(512)
+
+	info := contentInfo{mimeType: "application/octet-stream"}
+	if len(head) > 0 {
+		info.mimeType, _, _ = mime.ParseMediaType(http.DetectContentType(head))
+	}
+	for _, magic := range executableMagic {
+		if bytes.HasPrefix(head, magic) {
+			info.executable = true
+		}
+	}
+
+	if strings.HasPrefix(info.mimeType, "image/") {
+		if cfg, _, err := image.DecodeConfig(br); err == nil {
+			info.width, info.height = cfg.Width, cfg.Height
+		}
+	}
+	return info
+}
+
+// effectiveType reconciles the declared type with the sniffed one. Sniffing only knows
+// a limited set of formats, so the declared type is kept when it refines what was
+// detected (an office document is a zip, an m4a an mp4) or when the content was not
+// recognised at all. It returns "" when the two contradict each other.
+func effectiveType(declared, detected string) string {
+	switch {
+	case declared == "" || declared == "application/octet-stream" || declared == detected:
+		return detected
+	case detected == "application/octet-stream":
+		// every image format we accept is recognised by sniffing
+		if strings.HasPrefix(declared, "image/") {
+			return ""
+		}
+		return declared
+	case detected == "application/zip" && strings.HasPrefix(declared, "application/"):
+		return declared
+	case detected == "video/mp4" && (strings.HasPrefix(declared, "video/") || strings.HasPrefix(declared, "audio/")):
+		return declared
+	case strings.HasPrefix(detected, "text/plain") && strings.HasPrefix(declared, "text/"):
+		return declared
+	}
+	return ""
+}
+
+func (h *MediaUsecase) policy(purpose domain.MediaPurpose) config.UploadPolicy {
+	if p, ok := h.policies[purpose]; ok {
+		return p
+	}
+	return defaultPolicies[purpose]
+}
+
+// checkDeclared validates what the client announces before any byte is uploaded.
+func (h *MediaUsecase) checkDeclared(purpose domain.MediaPurpose, mimeType string, size int64) error {
+	v := policyViolations{}
+	checkType(v, h.policy(purpose), purpose, mimeType)
+	checkSize(v, h.policy(purpose), size)
+	return v.err()
+}
+
+// checkContent validates the uploaded bytes and returns the content type to record.
+func (h *MediaUsecase) checkContent(purpose domain.MediaPurpose, declared, filename string, size int64, info contentInfo) (string, error) {
+	policy := h.policy(purpose)
+	v := policyViolations{}
+
+	if _, ok := forbiddenExtensions[strings.ToLower(filepath.Ext(filename))]; ok {
+		v["filename"] = "file extension is not allowed"
+	}
+	if info.executable {
+		v["content"] = "executable files are not allowed"
+	}
+
+	mimeType := effectiveType(declared, info.mimeType)
+	if mimeType == "" {
+		v["content_type"] = fmt.Sprintf("content is %s, not %s", info.mimeType, declared)
+	} else {
+		checkType(v, policy, purpose, mimeType)
+	}
+	checkSize(v, policy, size)
+
+	if policy.MaxWidth > 0 && info.width > policy.MaxWidth {
+		v["width"] = fmt.Sprintf("image is wider than %d pixels", policy.MaxWidth)
+	}
+	if policy.MaxHeight > 0 && info.height > policy.MaxHeight {
+		v["height"] = fmt.Sprintf("image is taller than %d pixels", policy.MaxHeight)
+	}
+
+	return mimeType, v.err()
+}
+
+// forbiddenType reports whether browsers could run content of the type. Any +xml type
+// (image/svg+xml, application/xhtml+xml...) is an XML document.
+func forbiddenType(mimeType string) bool {
+	if _, ok := forbiddenTypes[mimeType]; ok {
+		return true
+	}
+	return strings.HasSuffix(mimeType, "+xml")
+}
+
+func checkType(v policyViolations, policy config.UploadPolicy, purpose domain.MediaPurpose, mimeType string) {
+	if forbiddenType(mimeType) {
+		v["content_type"] = fmt.Sprintf("%s files are not allowed", mimeType)
+		return
+	}
+	for _, allowed := range policy.AllowedTypes {
+		if allowed == "*/*" || allowed == mimeType ||
+			(strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(allowed, "*"))) {
+			return
+		}
+	}
+	v["content_type"] = fmt.Sprintf("%s is not allowed for %s uploads", mimeType, purpose)
+}
+
+func checkSize(v policyViolations, policy config.UploadPolicy, size int64) {
+	if policy.MaxSize > 0 && size > policy.MaxSize {
+		v["size"] = fmt.Sprintf("file is larger than %d bytes", policy.MaxSize)
+	}
+}
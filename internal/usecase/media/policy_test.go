@@ -0,0 +1,60 @@
+package media
+
+import "testing"
+
+func TestEffectiveType(t *testing.T) {
+	docx := "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+	tests := []struct {
+		name     string
+		declared string
+		detected string
+		want     string
+	}{
+		{"nothing declared", "", "image/png", "image/png"},
+		{"generic declared", "application/octet-stream", "text/plain", "text/plain"},
+		{"same type", "image/png", "image/png", "image/png"},
+		{"unrecognised content", "application/pdf", "application/octet-stream", "application/pdf"},
+		{"unrecognised image", "image/png", "application/octet-stream", ""},
+		{"office document is a zip", docx, "application/zip", docx},
+		{"zip is not an image", "image/png", "application/zip", ""},
+		{"m4a is an mp4", "audio/mp4", "video/mp4", "audio/mp4"},
+		{"csv is plain text", "text/csv", "text/plain", "text/csv"},
+		{"plain text is not an image", "image/jpeg", "text/plain", ""},
+		{"images contradict", "image/png", "image/jpeg", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveType(tt.declared, tt.detected); got != tt.want {
+				t.Errorf("effectiveType(%q, %q) = %q, want %q", tt.declared, tt.detected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForbiddenType(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		want     bool
+	}{
+		{"text/html", true},
+		{"application/javascript", true},
+		{"text/xml", true},
+		{"application/xml", true},
+		{"image/svg+xml", true},
+		{"application/xhtml+xml", true},
+		{"application/rss+xml", true},
+		{"image/png", false},
+		{"application/json", false},
+		{"text/plain", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mimeType, func(t *testing.T) {
+			if got := forbiddenType(tt.mimeType); got != tt.want {
+				t.Errorf("forbiddenType(%q) = %v, want %v", tt.mimeType, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/imaging"
+)
+
+// RunImageProcessing generates thumbnails for newly uploaded images until ctx is cancelled.
+func (h *MediaUsecase) RunImageProcessing(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			objs, err := h.mediaStore.ClaimPendingImages(ctx, processingBatch, processingTimeout)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error().Err(err).Msg("failed to claim images for processing")
+				}
+				break
+			}
+			for i := range objs {
+				h.processClaimed(ctx, &objs[i])
+			}
+			if len(objs) < processingBatch {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *MediaUsecase) processClaimed(ctx context.Context, obj *domain.MediaObject) {
+	err := h.processImage(ctx, obj)
+	if err == nil {
+		err = h.mediaStore.SaveProcessed(ctx, obj)
+	}
+	if err == nil {
+		return
+	}
+
+	h.logger.Warn().Err(err).Str("object_key", obj.StorageKey).Msg("failed to process image")
+	if err := h.mediaStore.ReleaseProcessing(ctx, obj.ID, maxProcessingAttempts); err != nil {
+		h.logger.Error().Err(err).Str("object_key", obj.StorageKey).Msg("failed to release image")
+	}
+}
+
+// processImage strips the metadata of the original, stores every variant and fills in
+// the dimensions and placeholder of obj.
+func (h *MediaUsecase) processImage(ctx context.Context, obj *domain.MediaObject) error {
+	if obj.SizeBytes > maxProcessBytes {
+		return fmt.Errorf("image of %d bytes is too large to process", obj.SizeBytes)
+	}
+
+	data, err := h.readObject(ctx, obj.StorageKey)
+	if err != nil {
+		return err
+	}
+
+	img, format, err := imaging.Decode(data, maxProcessPixels)
+	if err != nil {
+		return err
+	}
+
+	// Stripping EXIF also drops the orientation, so a rotated JPEG is stored upright.
+	// Otherwise the metadata is cut out without touching the image data.
+	var clean []byte
+	if format == "jpeg" && imaging.Orientation(data) != 1 {
+		clean, err = imaging.EncodeJPEG(img, originalQuality)
+	} else {
+		clean, err = imaging.StripMetadata(data, format)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(clean, data) {
+		if _, err := h.storage.Put(ctx, obj.StorageKey, bytes.NewReader(clean), int64(len(clean)), obj.MimeType); err != nil {
+			return err
+		}
+		checksum, _ := checksumOf(bytes.NewReader(clean))
+		obj.SizeBytes = int64(len(clean))
+		obj.Checksum = &checksum
+	}
+
+	for variant, size := range domain.VariantSizes {
+		out, err := imaging.EncodeJPEG(imaging.Fit(img, size), variantQuality)
+		if err != nil {
+			return err
+		}
+		if _, err := h.storage.Put(ctx, domain.VariantKey(obj.StorageKey, variant), bytes.NewReader(out), int64(len(out)), "image/jpeg"); err != nil {
+			return err
+		}
+	}
+
+	placeholder, err := imaging.Placeholder(img)
+	if err != nil {
+		return err
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	obj.Width, obj.Height, obj.Placeholder = &width, &height, &placeholder
+	obj.Processing = domain.ProcessingReady
+	return nil
+}
+
+func (h *MediaUsecase) readObject(ctx context.Context, objectName string) ([]byte, error) {
+	r, err := h.storage.Get(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(io.LimitReader(r, maxProcessBytes+1))
+}
@@ -0,0 +1,456 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/minio"
+	mediaRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/media"
+)
+
+// CreateResumableUpload opens an S3 multipart upload for a large file. The client then
+// sends the file in chunks of the returned chunk size with AppendChunk.
+func (h *MediaUsecase) CreateResumableUpload(ctx context.Context, userID uint64, req CreateResumableUploadRequest) (*ResumableUploadResponse, error) {
+	if !req.Purpose.Valid() {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid upload purpose")
+	}
+	if req.Size <= 0 || req.Size > maxResumableSize {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid upload size")
+	}
+	mediaType, _, err := mime.ParseMediaType(req.MimeType)
+	if err != nil {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid mime type")
+	}
+	if err := h.checkDeclared(req.Purpose, mediaType, req.Size); err != nil {
+		return nil, err
+	}
+
+	// checked again under a lock below, this only spares S3 requests over the quota
+	if err := checkUploadQuota(ctx, h.mediaStore, userID, req.Size); err != nil {
+		return nil, err
+	}
+
+	objectKey := newObjectKey(userID, extensionFor(mediaType))
+
+	multipartID, err := h.storage.NewMultipartUpload(ctx, objectKey, mediaType)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	up := &domain.ResumableUpload{
+		ID:          newUploadID(),
+		UserID:      userID,
+		ObjectKey:   objectKey,
+		MultipartID: multipartID,
+		Purpose:     req.Purpose,
+		MimeType:    mediaType,
+		Size:        req.Size,
+		ChunkSize:   resumableChunkSize,
+		ExpiresAt:   time.Now().Add(resumableUploadTTL),
+	}
+	err = h.uow.Do(ctx, func(tx *sql.Tx) error {
+		mediaTx := h.mediaStore.WithTx(tx)
+
+		if err := mediaTx.LockUploads(ctx, userID); err != nil {
+			return err
+		}
+		if err := checkUploadQuota(ctx, mediaTx, userID, req.Size); err != nil {
+			return err
+		}
+		return mediaTx.CreateUpload(ctx, up)
+	})
+	if err != nil {
+		h.abortMultipart(up)
+		var ae *apperr.AppError
+		if errors.As(err, &ae) {
+			return nil, err
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	return toResumableUploadResponse(up), nil
+}
+
+// GetResumableUpload returns the state of an upload, used by a client to find the
+// offset to resume from.
+func (h *MediaUsecase) GetResumableUpload(ctx context.Context, userID uint64, uploadID string) (*ResumableUploadResponse, error) {
+	up, err := h.getUpload(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return toResumableUploadResponse(up), nil
+}
+
+// AppendChunk stores the chunk starting at offset. The offset must be the current
+// offset of the upload and every chunk but the last must be exactly the chunk size.
+// Only one request at a time may store the chunk at an offset; others, including a
+// client retrying a chunk still in flight, are answered with a conflict carrying the
+// current offset.
+func (h *MediaUsecase) AppendChunk(ctx context.Context, userID uint64, uploadID string, offset int64, body io.Reader, length int64) (*ResumableUploadResponse, error) {
+	up, err := h.getUpload(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkActive(up); err != nil {
+		return nil, err
+	}
+	if offset != up.Offset {
+		return nil, offsetConflict(up.Offset)
+	}
+
+	// every byte is in already, this retries a completion that failed before
+	if up.Offset == up.Size {
+		return h.finishUpload(ctx, up)
+	}
+
+	if length != min(up.ChunkSize, up.Size-offset) {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid chunk length")
+	}
+
+	// claim the offset before writing the part, so two requests never race on the same
+	// part number and leave an ETag behind that S3 no longer has
+	claimID := newUploadID()
+	claimedUntil := time.Now().Add(chunkClaimTimeout)
+	ok, err := h.mediaStore.ClaimChunk(ctx, up.ID, offset, claimID, claimedUntil)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if !ok {
+		current, err := h.getUpload(ctx, userID, uploadID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, offsetConflict(current.Offset)
+	}
+
+	resp, err := h.storeChunk(ctx, up, claimID, claimedUntil, body, length)
+	if err != nil {
+		h.releaseChunk(up, claimID)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// storeChunk writes the chunk at the upload's offset as one S3 part. The part must be
+// written before the claim expires, after that another request may take the chunk over.
+func (h *MediaUsecase) storeChunk(ctx context.Context, up *domain.ResumableUpload, claimID string, claimedUntil time.Time, body io.Reader, length int64) (*ResumableUploadResponse, error) {
+	offset := up.Offset
+
+	// the first chunk carries the file header, check the content before storing anything
+	if offset == 0 {
+		chunk, err := io.ReadAll(io.LimitReader(body, length))
+		if err != nil {
+			return nil, apperr.Wrap(apperr.CodeBadRequest, http.StatusBadRequest, "failed to read chunk", err)
+		}
+		if _, err := h.checkContent(up.Purpose, up.MimeType, "", up.Size, inspect(bytes.NewReader(chunk))); err != nil {
+			if err := h.mediaStore.SetUploadStatus(ctx, up.ID, domain.UploadStatusAborted); err != nil {
+				h.logger.Error().Err(err).Str("upload_id", up.ID).Msg("failed to abort rejected upload")
+			}
+			h.abortMultipart(up)
+			return nil, err
+		}
+		body = bytes.NewReader(chunk)
+	}
+
+	sum, err := restoreHash(up.HashState)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	part := &domain.UploadPart{
+		UploadID:   up.ID,
+		PartNumber: int(offset/up.ChunkSize) + 1,
+		Size:       length,
+	}
+	putCtx, cancel := context.WithDeadline(ctx, claimedUntil)
+	defer cancel()
+	part.ETag, err = h.storage.PutPart(putCtx, up.ObjectKey, up.MultipartID, part.PartNumber, io.TeeReader(io.LimitReader(body, length), sum), length)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	hashState, err := sum.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	expiresAt := time.Now().Add(resumableUploadTTL)
+	err = h.uow.Do(ctx, func(tx *sql.Tx) error {
+		mediaTx := h.mediaStore.WithTx(tx)
+
+		ok, err := mediaTx.AdvanceUpload(ctx, up.ID, claimID, offset, offset+length, hashState, expiresAt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// the claim expired and another request took the chunk over
+			current, err := mediaTx.GetUpload(ctx, up.ID)
+			if err != nil {
+				return err
+			}
+			return offsetConflict(current.Offset)
+		}
+		return mediaTx.SaveUploadPart(ctx, part)
+	})
+	if err != nil {
+		var ae *apperr.AppError
+		if errors.As(err, &ae) {
+			return nil, err
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	up.Offset = offset + length
+	up.HashState = hashState
+	up.ExpiresAt = expiresAt
+	if up.Offset == up.Size {
+		return h.finishUpload(ctx, up)
+	}
+	return toResumableUploadResponse(up), nil
+}
+
+// AbortResumableUpload cancels an upload in progress and frees the stored parts.
+func (h *MediaUsecase) AbortResumableUpload(ctx context.Context, userID uint64, uploadID string) error {
+	up, err := h.getUpload(ctx, userID, uploadID)
+	if err != nil {
+		return err
+	}
+	if up.Status == domain.UploadStatusCompleted {
+		return apperr.New(apperr.CodeConflict, http.StatusConflict, "upload is already completed")
+	}
+	if up.Status == domain.UploadStatusAborted {
+		return nil
+	}
+
+	if err := h.mediaStore.SetUploadStatus(ctx, up.ID, domain.UploadStatusAborted); err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	h.abortMultipart(up)
+	return nil
+}
+
+// RunUploadCleanup aborts abandoned resumable uploads and retries failed media deletes
+// until ctx is cancelled.
+func (h *MediaUsecase) RunUploadCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			uploads, err := h.mediaStore.ExpireUploads(ctx, uploadCleanupBatch)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error().Err(err).Msg("failed to expire resumable uploads")
+				}
+				break
+			}
+			for i := range uploads {
+				h.abortMultipart(&uploads[i])
+			}
+			if len(uploads) > 0 {
+				h.logger.Info().Int("count", len(uploads)).Msg("aborted abandoned uploads")
+			}
+			if len(uploads) < uploadCleanupBatch {
+				break
+			}
+		}
+		h.retryDeletes(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryDeletes removes the stored objects of media whose delete failed half way.
+func (h *MediaUsecase) retryDeletes(ctx context.Context) {
+	objs, err := h.mediaStore.GetDeleted(ctx, deleteRetryAfter, uploadCleanupBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			h.logger.Error().Err(err).Msg("failed to load deleted media")
+		}
+		return
+	}
+	for i := range objs {
+		if err := h.removeObject(ctx, &objs[i]); err != nil {
+			h.logger.Warn().Err(err).Str("object_key", objs[i].StorageKey).Msg("failed to delete media")
+		}
+	}
+}
+
+// finishUpload assembles the parts into the final object and records it as the user's media.
+func (h *MediaUsecase) finishUpload(ctx context.Context, up *domain.ResumableUpload) (*ResumableUploadResponse, error) {
+	parts, err := h.mediaStore.GetUploadParts(ctx, up.ID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	storageParts := make([]minio.Part, 0, len(parts))
+	for _, p := range parts {
+		storageParts = append(storageParts, minio.Part{Number: p.PartNumber, ETag: p.ETag})
+	}
+	if err := h.storage.CompleteMultipartUpload(ctx, up.ObjectKey, up.MultipartID, storageParts); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	info, err := h.storage.Stat(ctx, up.ObjectKey)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if info.Size != up.Size {
+		if err := h.storage.Delete(ctx, up.ObjectKey); err != nil {
+			h.logger.Error().Err(err).Str("object_key", up.ObjectKey).Msg("failed to delete rejected upload")
+		}
+		if err := h.mediaStore.SetUploadStatus(ctx, up.ID, domain.UploadStatusAborted); err != nil {
+			h.logger.Error().Err(err).Str("upload_id", up.ID).Msg("failed to abort rejected upload")
+		}
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "uploaded file does not match the declared size")
+	}
+
+	sum, err := restoreHash(up.HashState)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	checksum := hex.EncodeToString(sum.Sum(nil))
+
+	obj := &domain.MediaObject{
+		OwnerID:    up.UserID,
+		StorageKey: up.ObjectKey,
+		Purpose:    up.Purpose,
+		MimeType:   up.MimeType,
+		SizeBytes:  info.Size,
+		Checksum:   &checksum,
+	}
+	if err := h.mediaStore.Create(ctx, obj); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if err := h.mediaStore.SetUploadStatus(ctx, up.ID, domain.UploadStatusCompleted); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	up.Status = domain.UploadStatusCompleted
+
+	url, err := h.storage.PresignGet(ctx, obj.StorageKey, 24*time.Hour)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	resp := toResumableUploadResponse(up)
+	resp.Media = &MediaObjectResponse{
+		ObjectKey: obj.StorageKey,
+		Purpose:   obj.Purpose,
+		MimeType:  obj.MimeType,
+		Size:      obj.SizeBytes,
+		URL:       url,
+	}
+	return resp, nil
+}
+
+func (h *MediaUsecase) getUpload(ctx context.Context, userID uint64, uploadID string) (*domain.ResumableUpload, error) {
+	if uploadID == "" {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "upload id is required")
+	}
+
+	up, err := h.mediaStore.GetUpload(ctx, uploadID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "upload not found")
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if up.UserID != userID {
+		return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "upload not found")
+	}
+	return up, nil
+}
+
+// releaseChunk lets another request store the chunk right away instead of waiting for
+// the claim to expire. Failures are only logged, the claim expires on its own.
+func (h *MediaUsecase) releaseChunk(up *domain.ResumableUpload, claimID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.mediaStore.ReleaseChunk(ctx, up.ID, claimID); err != nil {
+		h.logger.Error().Err(err).Str("upload_id", up.ID).Msg("failed to release upload chunk")
+	}
+}
+
+// abortMultipart frees the parts stored for an upload. Failures are only logged, S3
+// lifecycle rules remove incomplete multipart uploads eventually.
+func (h *MediaUsecase) abortMultipart(up *domain.ResumableUpload) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := h.storage.AbortMultipartUpload(ctx, up.ObjectKey, up.MultipartID); err != nil {
+		h.logger.Error().Err(err).Str("upload_id", up.ID).Str("object_key", up.ObjectKey).Msg("failed to abort multipart upload")
+	}
+}
+
+// restoreHash continues the SHA-256 of an upload from the state saved with its last chunk.
+func restoreHash(state []byte) (hash.Hash, error) {
+	sum := sha256.New()
+	if len(state) > 0 {
+		if err := sum.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			return nil, err
+		}
+	}
+	return sum, nil
+}
+
+// checkUploadQuota refuses a new upload that would take the user past their quota of
+// uploads in progress.
+func checkUploadQuota(ctx context.Context, store mediaRepo.MediaStore, userID uint64, size int64) error {
+	count, pending, err := store.GetActiveUploadUsage(ctx, userID)
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if count >= maxActiveUploads {
+		return apperr.New(apperr.CodeRateLimited, http.StatusTooManyRequests, "too many uploads in progress")
+	}
+	if pending+size > maxPendingUploadBytes {
+		return apperr.New(apperr.CodeRateLimited, http.StatusTooManyRequests, "upload quota exceeded")
+	}
+	return nil
+}
+
+func checkActive(up *domain.ResumableUpload) error {
+	switch {
+	case up.Status == domain.UploadStatusCompleted:
+		return apperr.New(apperr.CodeConflict, http.StatusConflict, "upload is already completed")
+	case up.Status == domain.UploadStatusAborted, time.Now().After(up.ExpiresAt):
+		return apperr.New(apperr.CodeNotFound, http.StatusGone, "upload has expired or was aborted")
+	}
+	return nil
+}
+
+// offsetConflict tells the client where to resume from.
+func offsetConflict(offset int64) error {
+	err := apperr.New(apperr.CodeConflict, http.StatusConflict, "offset does not match the upload")
+	err.Fields = map[string]string{"offset": strconv.FormatInt(offset, 10)}
+	return err
+}
+
+func toResumableUploadResponse(up *domain.ResumableUpload) *ResumableUploadResponse {
+	return &ResumableUploadResponse{
+		UploadID:  up.ID,
+		ObjectKey: up.ObjectKey,
+		Size:      up.Size,
+		ChunkSize: up.ChunkSize,
+		Offset:    up.Offset,
+		Status:    up.Status,
+		ExpiresAt: up.ExpiresAt,
+	}
+}
@@ -1,19 +1,81 @@
 package media
 
 import (
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/config"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/minio"
+	mediaRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/media"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/uow"
+	redisStore "github.com/Jaxongir1006/Chat-X-v2/internal/infra/redis/store"
 	"github.com/rs/zerolog"
 )
 
+const (
+	// maxUploadSize caps a single direct upload, well below the 5 GiB limit of a single S3 PUT.
+	maxUploadSize = 2 << 30
+
+	// uploadURLExpiry is how long a client has to upload after requesting a slot.
+	uploadURLExpiry = 15 * time.Minute
+
+	// resumableChunkSize is the size of every chunk of a resumable upload but the last.
+	// Each chunk becomes one S3 part, which must be at least 5 MiB.
+	resumableChunkSize = 8 << 20
+	maxResumableSize   = 4 << 30
+
+	// per user quotas on uploads that are still in progress
+	maxActiveUploads      = 5
+	maxPendingUploadBytes = 10 << 30
+
+	// resumableUploadTTL is how long an upload may sit idle before it is abandoned.
+	// Every received chunk pushes the expiry forward.
+	resumableUploadTTL = 24 * time.Hour
+
+	// chunkClaimTimeout is how long a request may take to store a chunk before another
+	// request may take the chunk over.
+	chunkClaimTimeout = 10 * time.Minute
+
+	uploadCleanupBatch = 100
+	// deleteRetryAfter is how long a deleted object is left to the request deleting it
+	// before the cleanup retries.
+	deleteRetryAfter = 5 * time.Minute
+
+	// image processing limits; larger images keep only their original
+	maxProcessBytes  = 50 << 20
+	maxProcessPixels = 50_000_000
+
+	processingBatch       = 10
+	maxProcessingAttempts = 3
+	// processingTimeout is how long a claimed image may take before another worker retries it.
+	processingTimeout = 10 * time.Minute
+
+	variantQuality = 80
+	// originalQuality is used when an original must be re-encoded to apply its EXIF rotation.
+	originalQuality = 90
+)
 
 type MediaUsecase struct {
-	storage minio.ObjectStorage
-	logger  zerolog.Logger
+	storage    minio.ObjectStorage
+	mediaStore mediaRepo.MediaStore
+	intents    redisStore.UploadIntentStore
+	uow        uow.UnitOfWork
+	policies   map[domain.MediaPurpose]config.UploadPolicy
+	logger     zerolog.Logger
 }
 
-func NewMediaUsecase(storage minio.ObjectStorage, logger zerolog.Logger) *MediaUsecase {
+func NewMediaUsecase(storage minio.ObjectStorage, mediaStore mediaRepo.MediaStore, intents redisStore.UploadIntentStore, uow uow.UnitOfWork, cfg config.MediaConfig, logger zerolog.Logger) *MediaUsecase {
+	policies := make(map[domain.MediaPurpose]config.UploadPolicy, len(cfg.UploadPolicies))
+	for purpose, policy := range cfg.UploadPolicies {
+		policies[domain.MediaPurpose(purpose)] = policy
+	}
+
 	return &MediaUsecase{
-		storage: storage,
-		logger:  logger,
+		storage:    storage,
+		mediaStore: mediaStore,
+		intents:    intents,
+		uow:        uow,
+		policies:   policies,
+		logger:     logger,
 	}
-}
\ No newline at end of file
+}
@@ -2,25 +2,64 @@ package media
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"time"
 
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/minio"
 )
 
 
-func (h *MediaUsecase) UploadMedia(ctx context.Context, file multipart.File, filename string, size int64, contentType string) (*UploadMediaResponse, error) {
-	ext := filepath.Ext(filename)
-	objectName := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
+func (h *MediaUsecase) UploadMedia(ctx context.Context, userID uint64, purpose domain.MediaPurpose, file multipart.File, filename string, size int64, contentType string) (*UploadMediaResponse, error) {
+	if !purpose.Valid() {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid upload purpose")
+	}
+
+	// the type is taken from the content; the header and the filename are only hints
+	info := inspect(file)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	mimeType, err := h.checkContent(purpose, contentType, filename, size, info)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := extensionFor(mimeType)
+	if ext == "" {
+		ext = filepath.Ext(filename)
+	}
+	objectName := newObjectKey(userID, ext)
+
+	checksum, err := checksumOf(file)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeBadRequest, http.StatusBadRequest, "failed to read file", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
 
-	_, err := h.storage.Upload(ctx, objectName, file, size, contentType)
+	_, err = h.storage.Upload(ctx, objectName, file, size, mimeType)
 	if err != nil {
 		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
 	}
 
+	obj := &domain.MediaObject{OwnerID: userID, StorageKey: objectName, Purpose: purpose, MimeType: mimeType, SizeBytes: size, Checksum: &checksum}
+	if err := h.mediaStore.Create(ctx, obj); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
 	mediaURL, err := h.storage.PresignGet(ctx, objectName, 24*time.Hour)
 	if err != nil {
 		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
@@ -33,13 +72,221 @@ func (h *MediaUsecase) UploadMedia(ctx context.Context, file multipart.File, fil
 }
 
 
-func (h *MediaUsecase) DeleteMedia(ctx context.Context, objectName string) error {
+// DeleteMedia removes an object of the user that no profile image or message uses any more.
+func (h *MediaUsecase) DeleteMedia(ctx context.Context, userID uint64, objectName string) error {
 	if objectName == "" {
 		return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "object name is required")
 	}
 
-	if err := h.storage.Delete(ctx, objectName); err != nil {
+	obj, err := h.mediaStore.GetByKey(ctx, objectName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.New(apperr.CodeNotFound, http.StatusNotFound, "media not found")
+		}
 		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
 	}
+	if obj.OwnerID != userID {
+		return apperr.New(apperr.CodeForbidden, http.StatusForbidden, "media does not belong to you")
+	}
+	if obj.RefCount > 0 {
+		return apperr.New(apperr.CodeConflict, http.StatusConflict, "media is still in use")
+	}
+
+	// the count is checked again by the tombstone itself, a reference may have been added since
+	deleted, err := h.mediaStore.MarkDeleted(ctx, objectName, userID)
+	if err != nil {
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if !deleted {
+		return apperr.New(apperr.CodeConflict, http.StatusConflict, "media is still in use")
+	}
+
+	// the media is gone for the user either way, the cleanup retries a failed delete
+	if err := h.removeObject(ctx, obj); err != nil {
+		h.logger.Warn().Err(err).Str("object_key", objectName).Msg("failed to delete media, left for the cleanup")
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// removeObject deletes a tombstoned object and its image variants from storage, then
+// drops its record.
+func (h *MediaUsecase) removeObject(ctx context.Context, obj *domain.MediaObject) error {
+	keys := []string{obj.StorageKey}
+	if domain.ProcessableImage(obj.MimeType) {
+		for variant := range domain.VariantSizes {
+			keys = append(keys, domain.VariantKey(obj.StorageKey, variant))
+		}
+	}
+	for _, key := range keys {
+		if err := h.storage.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return h.mediaStore.Purge(ctx, obj.StorageKey)
+}
+
+// CreateUpload hands out a presigned PUT URL for a user scoped object key. The upload is
+// only recorded as the user's media once CompleteUpload has checked the stored object.
+func (h *MediaUsecase) CreateUpload(ctx context.Context, userID uint64, req CreateUploadRequest) (*CreateUploadResponse, error) {
+	if !req.Purpose.Valid() {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid upload purpose")
+	}
+	if req.Size <= 0 || req.Size > maxUploadSize {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid upload size")
+	}
+	mediaType, _, err := mime.ParseMediaType(req.MimeType)
+	if err != nil {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid mime type")
+	}
+	if err := h.checkDeclared(req.Purpose, mediaType, req.Size); err != nil {
+		return nil, err
+	}
+
+	intent := &domain.UploadIntent{
+		ID:        newUploadID(),
+		UserID:    userID,
+		ObjectKey: newObjectKey(userID, extensionFor(mediaType)),
+		Purpose:   req.Purpose,
+		MimeType:  mediaType,
+		Size:      req.Size,
+		ExpiresAt: time.Now().Add(uploadURLExpiry),
+	}
+
+	url, err := h.storage.PresignPut(ctx, intent.ObjectKey, uploadURLExpiry)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	// keep the intent a little longer than the URL so an upload finishing at the last
+	// second can still be completed
+	if err := h.intents.SaveUploadIntent(ctx, intent, 2*uploadURLExpiry); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	return &CreateUploadResponse{
+		UploadID:  intent.ID,
+		ObjectKey: intent.ObjectKey,
+		UploadURL: url,
+		ExpiresAt: intent.ExpiresAt,
+	}, nil
+}
+
+// CompleteUpload checks the object uploaded for an intent against what was declared and
+// records it as media owned by the user. An object that does not match is removed.
+func (h *MediaUsecase) CompleteUpload(ctx context.Context, userID uint64, req CompleteUploadRequest) (*MediaObjectResponse, error) {
+	intent, err := h.intents.GetUploadIntent(ctx, req.UploadID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if intent == nil || intent.UserID != userID {
+		return nil, apperr.New(apperr.CodeNotFound, http.StatusNotFound, "upload not found")
+	}
+
+	info, err := h.storage.Stat(ctx, intent.ObjectKey)
+	if err != nil {
+		if errors.Is(err, minio.ErrObjectNotFound) {
+			return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "file has not been uploaded")
+		}
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	storedType, _, _ := mime.ParseMediaType(info.ContentType)
+	if info.Size != intent.Size || storedType != intent.MimeType {
+		if err := h.storage.Delete(ctx, intent.ObjectKey); err != nil {
+			h.logger.Error().Err(err).Str("object_key", intent.ObjectKey).Msg("failed to delete rejected upload")
+		}
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "uploaded file does not match the declared size or type")
+	}
+
+	contentInfo, err := h.inspectStored(ctx, intent.ObjectKey)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if _, err := h.checkContent(intent.Purpose, intent.MimeType, "", info.Size, contentInfo); err != nil {
+		if err := h.storage.Delete(ctx, intent.ObjectKey); err != nil {
+			h.logger.Error().Err(err).Str("object_key", intent.ObjectKey).Msg("failed to delete rejected upload")
+		}
+		return nil, err
+	}
+
+	// the file went straight to storage, read it back once to checksum it
+	checksum, err := h.storedChecksum(ctx, intent.ObjectKey)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	obj := &domain.MediaObject{
+		OwnerID:    userID,
+		StorageKey: intent.ObjectKey,
+		Purpose:    intent.Purpose,
+		MimeType:   intent.MimeType,
+		SizeBytes:  info.Size,
+		Checksum:   &checksum,
+	}
+	if err := h.mediaStore.Create(ctx, obj); err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	if err := h.intents.DeleteUploadIntent(ctx, intent.ID); err != nil {
+		h.logger.Warn().Err(err).Str("upload_id", intent.ID).Msg("failed to delete completed upload intent")
+	}
+
+	url, err := h.storage.PresignGet(ctx, obj.StorageKey, 24*time.Hour)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+
+	return &MediaObjectResponse{
+		ObjectKey: obj.StorageKey,
+		Purpose:   obj.Purpose,
+		MimeType:  obj.MimeType,
+		Size:      obj.SizeBytes,
+		URL:       url,
+	}, nil
+}
+
+func (h *MediaUsecase) inspectStored(ctx context.Context, objectName string) (contentInfo, error) {
+	obj, err := h.storage.Get(ctx, objectName)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	defer obj.Close()
+
+	return inspect(obj), nil
+}
+
+func (h *MediaUsecase) storedChecksum(ctx context.Context, objectName string) (string, error) {
+	obj, err := h.storage.Get(ctx, objectName)
+	if err != nil {
+		return "", err
+	}
+	defer obj.Close()
+
+	return checksumOf(obj)
+}
+
+// checksumOf returns the hex SHA-256 of everything read from r.
+func checksumOf(r io.Reader) (string, error) {
+	sum := sha256.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+func extensionFor(mimeType string) string {
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+func newObjectKey(userID uint64, ext string) string {
+	return fmt.Sprintf("%s%d%s", domain.UserMediaPrefix(userID), time.Now().UnixNano(), ext)
+}
+
+func newUploadID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/config"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/kafka/producer"
+	outboxRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/outbox"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/uow"
+	"github.com/rs/zerolog"
+)
+
+// OutboxRelay drains outbox_events into Kafka. Events are marked as published only
+// after Kafka acknowledged them, so delivery is at-least-once: consumers must
+// de-duplicate with the idempotency-key header.
+type OutboxRelay struct {
+	store     outboxRepo.OutboxStore
+	uow       uow.UnitOfWork
+	kafka     config.KafkaConfig
+	producers map[string]*producer.Producer
+	batchSize int
+	interval  time.Duration
+	logger    zerolog.Logger
+}
+
+func NewOutboxRelay(store outboxRepo.OutboxStore, uow uow.UnitOfWork, kafka config.KafkaConfig, batchSize int, interval time.Duration, logger zerolog.Logger) *OutboxRelay {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return &OutboxRelay{
+		store:     store,
+		uow:       uow,
+		kafka:     kafka,
+		producers: make(map[string]*producer.Producer),
+		batchSize: batchSize,
+		interval:  interval,
+		logger:    logger,
+	}
+}
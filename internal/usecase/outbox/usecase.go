@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/kafka/producer"
+)
+
+// Run polls the outbox until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.logger.Info().Int("batch_size", r.batchSize).Dur("interval", r.interval).Msg("outbox relay started")
+
+	for {
+		// drain everything that is pending before waiting for the next tick
+		for {
+			n, err := r.relayBatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					r.logger.Error().Err(err).Msg("failed to relay outbox batch")
+				}
+				break
+			}
+			if n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info().Msg("outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes every Kafka producer opened by the relay.
+func (r *OutboxRelay) Close() error {
+	var errs []error
+	for topic, p := range r.producers {
+		if err := p.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(r.producers, topic)
+	}
+	return errors.Join(errs...)
+}
+
+// relayBatch publishes one batch of pending events and returns how many were published.
+// The rows stay locked (SKIP LOCKED) while publishing, so several relays can run side by side.
+// Each topic's events go out in a single write. Events are keyed by conversation, so all
+// events of a conversation land on one partition and fail or succeed together; a failed
+// topic is retried as a whole on the next batch, keeping them in order.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	published := 0
+
+	err := r.uow.Do(ctx, func(tx *sql.Tx) error {
+		storeTx := r.store.WithTx(tx)
+
+		events, err := storeTx.FetchPending(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		var topics []string
+		byTopic := make(map[string][]domain.OutboxEvent)
+		for _, evt := range events {
+			if _, ok := byTopic[evt.Topic]; !ok {
+				topics = append(topics, evt.Topic)
+			}
+			byTopic[evt.Topic] = append(byTopic[evt.Topic], evt)
+		}
+
+		ids := make([]uint64, 0, len(events))
+		for _, topic := range topics {
+			batch := byTopic[topic]
+
+			msgs := make([]producer.Message, 0, len(batch))
+			batchIDs := make([]uint64, 0, len(batch))
+			for _, evt := range batch {
+				msgs = append(msgs, producer.Message{
+					Key:   []byte(strconv.FormatUint(evt.AggregateID, 10)),
+					Value: evt.Payload,
+					Headers: map[string]string{
+						"idempotency-key": evt.IdempotencyKey,
+						"event-type":      evt.EventType,
+					},
+				})
+				batchIDs = append(batchIDs, evt.ID)
+			}
+
+			// a topic that cannot be resolved fails like a write, so it never holds up the others
+			p, err := r.producer(topic)
+			if err == nil {
+				err = p.WriteMessages(ctx, msgs...)
+			}
+			if err != nil {
+				r.logger.Warn().Err(err).Str("topic", topic).Int("events", len(batch)).Msg("failed to publish outbox events")
+				if err := storeTx.MarkFailed(ctx, batchIDs, err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			ids = append(ids, batchIDs...)
+		}
+
+		if err := storeTx.MarkPublished(ctx, ids); err != nil {
+			return err
+		}
+		published = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, nil
+}
+
+func (r *OutboxRelay) producer(logicalTopic string) (*producer.Producer, error) {
+	topic, err := r.kafka.ResolveTopic(logicalTopic)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := r.producers[topic]
+	if !ok {
+		p = producer.NewProducer(r.kafka.BrokerAddr(), topic)
+		r.producers[topic] = p
+	}
+	return p, nil
+}
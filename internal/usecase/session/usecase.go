@@ -197,3 +197,19 @@ func (s *SessionUsecase) GetSessionsByUserID(ctx context.Context, userID uint64)
 func (s *SessionUsecase) RevokeSessionByID(ctx context.Context, sessionID, userID uint64) error {
 	return s.sessionStore.RevokeByID(ctx, sessionID, userID)
 }
+
+// IsSessionActive reports whether the session still exists, is not revoked and its refresh token is valid.
+func (s *SessionUsecase) IsSessionActive(ctx context.Context, sessionID uint64) (bool, error) {
+	sess, err := s.sessionStore.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if sess.RevokedAt != nil || time.Now().After(sess.RefreshTokenExp) {
+		return false, nil
+	}
+	return true, nil
+}
@@ -1,6 +1,10 @@
 package user
 
-import "time"
+import (
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
+)
 
 type UserResponse struct {
 	ID        uint64              `json:"id"`
@@ -24,10 +28,15 @@ type UserProfileResponse struct {
 }
 
 type UserProfileMediaDTO struct {
-	ID           uint64 `json:"id"`
-	MediaKey     string `json:"media_key"`
-	IsPrimary    bool   `json:"is_primary"`
-	DisplayOrder int    `json:"display_order"`
+	ID           uint64              `json:"id"`
+	MediaKey     string              `json:"media_key"`
+	URL          string              `json:"url,omitempty"` // presigned, valid for a limited time
+	Variant      domain.MediaVariant `json:"variant,omitempty"`
+	Width        *int                `json:"width,omitempty"`
+	Height       *int                `json:"height,omitempty"`
+	Placeholder  *string             `json:"placeholder,omitempty"`
+	IsPrimary    bool                `json:"is_primary"`
+	DisplayOrder int                 `json:"display_order"`
 }
 
 type UpdateProfileRequest struct {
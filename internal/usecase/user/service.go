@@ -1,6 +1,10 @@
 package user
 
 import (
+	"time"
+
+	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/minio"
+	mediaRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/media"
 	sessionInfra "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/session"
 	userInfra "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/user"
 	"github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/uow"
@@ -8,18 +12,25 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// mediaURLExpiry is how long the profile image URLs returned with a profile stay valid.
+const mediaURLExpiry = time.Hour
+
 type UserUsecase struct {
 	userStore userInfra.UserStore
 	session   sessionInfra.SessionStore
+	media     mediaRepo.MediaStore
+	storage   minio.ObjectStorage
 	uow       uow.UnitOfWork
 	hasher    security.Hasher
 	logger    zerolog.Logger
 }
 
-func NewUserUsecase(userStore userInfra.UserStore, sessionStore sessionInfra.SessionStore, uow uow.UnitOfWork, hasher security.Hasher, logger zerolog.Logger) *UserUsecase {
+func NewUserUsecase(userStore userInfra.UserStore, sessionStore sessionInfra.SessionStore, mediaStore mediaRepo.MediaStore, storage minio.ObjectStorage, uow uow.UnitOfWork, hasher security.Hasher, logger zerolog.Logger) *UserUsecase {
 	return &UserUsecase{
 		userStore: userStore,
 		session:   sessionStore,
+		media:     mediaStore,
+		storage:   storage,
 		uow:       uow,
 		hasher:    hasher,
 		logger:    logger,
@@ -6,10 +6,21 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/Jaxongir1006/Chat-X-v2/internal/domain"
 	apperr "github.com/Jaxongir1006/Chat-X-v2/internal/errors"
+	mediaRepo "github.com/Jaxongir1006/Chat-X-v2/internal/infra/postgres/repo/media"
 )
 
-func (u *UserUsecase) GetMe(ctx context.Context, userID uint64) (*UserResponse, error) {
+// GetMe returns the user's account and profile. Profile image URLs point at the
+// requested variant where it has been generated.
+func (u *UserUsecase) GetMe(ctx context.Context, userID uint64, variant domain.MediaVariant) (*UserResponse, error) {
+	if variant == "" {
+		variant = domain.VariantOriginal
+	}
+	if !variant.Valid() {
+		return nil, apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "invalid variant")
+	}
+
 	user, err := u.userStore.GetUserByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -40,6 +51,9 @@ func (u *UserUsecase) GetMe(ctx context.Context, userID uint64) (*UserResponse,
 			DisplayOrder: m.DisplayOrder,
 		})
 	}
+	if err := u.presignProfileMedia(ctx, mediaDTOs, variant); err != nil {
+		u.logger.Warn().Err(err).Uint64("user_id", userID).Msg("failed to presign profile media")
+	}
 
 	response := UserResponse{
 		ID:        user.ID,
@@ -77,6 +91,19 @@ func (u *UserUsecase) DeleteAccount(ctx context.Context, userID uint64) error {
 			return err
 		}
 
+		// profile images go with the user, release their media
+		images, err := userTx.GetProfileMedia(ctx, userID)
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(images))
+		for _, img := range images {
+			keys = append(keys, img.MediaKey)
+		}
+		if err := u.media.WithTx(tx).AddRefs(ctx, keys, -1); err != nil {
+			return err
+		}
+
 		err = userTx.DeleteUserProfile(ctx, userID)
 		if err != nil {
 			return err
@@ -118,12 +145,81 @@ func (u *UserUsecase) ChangePassword(ctx context.Context, userID uint64, req Cha
 	return u.userStore.UpdatePassword(ctx, userID, newHash)
 }
 
+// AddProfileMedia adds one of the user's own uploaded avatars to their profile.
 func (u *UserUsecase) AddProfileMedia(ctx context.Context, userID uint64, req AddProfileMediaRequest) error {
-	return u.userStore.AddProfileMedia(ctx, userID, req.MediaKey, req.IsPrimary)
+	obj, err := u.media.GetByKey(ctx, req.MediaKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.New(apperr.CodeNotFound, http.StatusNotFound, "media not found")
+		}
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	if obj.OwnerID != userID {
+		return apperr.New(apperr.CodeForbidden, http.StatusForbidden, "media does not belong to you")
+	}
+	if obj.Purpose != domain.MediaPurposeAvatar {
+		return apperr.New(apperr.CodeBadRequest, http.StatusBadRequest, "media was not uploaded as an avatar")
+	}
+
+	err = u.uow.Do(ctx, func(tx *sql.Tx) error {
+		if err := u.userStore.WithTx(tx).AddProfileMedia(ctx, userID, obj.StorageKey, req.IsPrimary); err != nil {
+			return err
+		}
+		return u.media.WithTx(tx).AddRefs(ctx, []string{obj.StorageKey}, 1)
+	})
+	if err != nil {
+		if errors.Is(err, mediaRepo.ErrMediaNotFound) {
+			return apperr.New(apperr.CodeNotFound, http.StatusNotFound, "media not found")
+		}
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	return nil
 }
 
 func (u *UserUsecase) DeleteProfileMedia(ctx context.Context, userID uint64, mediaID uint64) error {
-	return u.userStore.DeleteProfileMedia(ctx, userID, mediaID)
+	err := u.uow.Do(ctx, func(tx *sql.Tx) error {
+		key, err := u.userStore.WithTx(tx).DeleteProfileMedia(ctx, userID, mediaID)
+		if err != nil {
+			return err
+		}
+		return u.media.WithTx(tx).AddRefs(ctx, []string{key}, -1)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.New(apperr.CodeNotFound, http.StatusNotFound, "profile media not found")
+		}
+		return apperr.Wrap(apperr.CodeInternal, http.StatusInternalServerError, "INTERNAL SERVER ERROR", err)
+	}
+	return nil
+}
+
+func (u *UserUsecase) presignProfileMedia(ctx context.Context, media []UserProfileMediaDTO, variant domain.MediaVariant) error {
+	if len(media) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(media))
+	for _, m := range media {
+		keys = append(keys, m.MediaKey)
+	}
+	objs, err := u.media.GetByKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	for i := range media {
+		key, served := media[i].MediaKey, domain.VariantOriginal
+		if obj, ok := objs[key]; ok {
+			key, served = obj.ServeKey(variant)
+			media[i].Width, media[i].Height, media[i].Placeholder = obj.Width, obj.Height, obj.Placeholder
+		}
+		url, err := u.storage.PresignGet(ctx, key, mediaURLExpiry)
+		if err != nil {
+			return err
+		}
+		media[i].URL, media[i].Variant = url, served
+	}
+	return nil
 }
 
 func (u *UserUsecase) SetPrimaryProfileMedia(ctx context.Context, userID uint64, mediaID uint64) error {
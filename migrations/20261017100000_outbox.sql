@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS outbox_events (
+  id              BIGSERIAL PRIMARY KEY,
+
+  idempotency_key TEXT NOT NULL UNIQUE,   -- sent as a Kafka header so consumers can drop duplicates
+  topic           TEXT NOT NULL,          -- logical topic, resolved with KafkaConfig.ResolveTopic
+  event_type      TEXT NOT NULL,
+  aggregate_id    BIGINT NOT NULL,        -- used as the Kafka key to keep per-conversation ordering
+  payload         JSONB NOT NULL,
+
+  attempts        INT NOT NULL DEFAULT 0,
+  last_error      TEXT,
+
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  published_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox_events(id) WHERE published_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_pending;
+DROP TABLE IF EXISTS outbox_events;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS message_edits (
+  id          BIGSERIAL PRIMARY KEY,
+  message_id  BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+  editor_id   BIGINT NOT NULL,   -- FK -> users(id)
+
+  old_text    TEXT,              -- text before this edit
+  edited_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- "delete for me": the message stays for everyone else
+CREATE TABLE IF NOT EXISTS hidden_messages (
+  message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+  user_id    BIGINT NOT NULL,   -- FK -> users(id)
+  hidden_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_edits_msg ON message_edits(message_id, edited_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_message_edits_msg;
+DROP TABLE IF EXISTS hidden_messages;
+DROP TABLE IF EXISTS message_edits;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- unread counts scan only the messages after a participant's read watermark
+CREATE INDEX IF NOT EXISTS idx_messages_conv_id ON messages(conversation_id, id) WHERE deleted_at IS NULL;
+
+-- "read by" lists are the participants whose watermark reached the message
+CREATE INDEX IF NOT EXISTS idx_participants_conv_last_read ON conversation_participants(conversation_id, last_read_message_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_participants_conv_last_read;
+DROP INDEX IF EXISTS idx_messages_conv_id;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- forwards quote the original as it was when forwarded, so later edits and deletes of
+-- the original never show through to readers of the copy
+ALTER TABLE messages
+  ADD COLUMN IF NOT EXISTS forward_sender_id BIGINT;
+
+UPDATE messages m SET forward_sender_id = o.sender_id
+FROM messages o
+WHERE m.forward_from_id = o.id AND m.forward_sender_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE messages DROP COLUMN IF EXISTS forward_sender_id;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS media_objects (
+  id           BIGSERIAL PRIMARY KEY,
+  owner_id     BIGINT NOT NULL,           -- FK -> users(id), the uploader
+
+  storage_key  TEXT NOT NULL UNIQUE,
+  purpose      TEXT NOT NULL,             -- avatar | message_attachment | group_photo
+  mime_type    TEXT NOT NULL,
+  size_bytes   BIGINT NOT NULL,
+
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_objects_owner ON media_objects(owner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_media_objects_owner;
+DROP TABLE IF EXISTS media_objects;
+-- +goose StatementEnd
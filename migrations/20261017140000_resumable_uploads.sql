@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS resumable_uploads (
+  id            TEXT PRIMARY KEY,          -- random token handed to the client
+  user_id       BIGINT NOT NULL,           -- FK -> users(id)
+
+  object_key    TEXT NOT NULL UNIQUE,
+  multipart_id  TEXT NOT NULL,             -- S3 multipart upload id
+  purpose       TEXT NOT NULL,
+  mime_type     TEXT NOT NULL,
+
+  size_bytes    BIGINT NOT NULL,
+  chunk_size    BIGINT NOT NULL,
+  offset_bytes  BIGINT NOT NULL DEFAULT 0, -- bytes received so far
+
+  -- the request storing the chunk at offset_bytes holds claim_id until claimed_until,
+  -- so concurrent requests never write the same S3 part
+  claim_id      TEXT,
+  claimed_until TIMESTAMPTZ,
+
+  status        TEXT NOT NULL DEFAULT 'active', -- active | completed | aborted
+
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at    TIMESTAMPTZ NOT NULL       -- pushed forward on every chunk
+);
+
+CREATE TABLE IF NOT EXISTS resumable_upload_parts (
+  upload_id   TEXT NOT NULL REFERENCES resumable_uploads(id) ON DELETE CASCADE,
+  part_number INT NOT NULL,
+  etag        TEXT NOT NULL,
+  size_bytes  BIGINT NOT NULL,
+  PRIMARY KEY (upload_id, part_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_resumable_uploads_user_active ON resumable_uploads(user_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_resumable_uploads_expiry ON resumable_uploads(expires_at) WHERE status = 'active';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_resumable_uploads_expiry;
+DROP INDEX IF EXISTS idx_resumable_uploads_user_active;
+DROP TABLE IF EXISTS resumable_upload_parts;
+DROP TABLE IF EXISTS resumable_uploads;
+-- +goose StatementEnd
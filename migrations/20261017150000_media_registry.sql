@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS checksum TEXT;              -- hex SHA-256 of the content
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS ref_count INT NOT NULL DEFAULT 0; -- profile images and attachments using it
+ALTER TABLE media_objects ADD CONSTRAINT media_objects_ref_count_check CHECK (ref_count >= 0);
+
+-- a deleted object keeps its record until the stored object is gone, so a failed
+-- storage delete is retried instead of leaving the object orphaned
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_media_objects_deleted ON media_objects(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- running SHA-256 state of a resumable upload, so the checksum is known once the last chunk arrives
+ALTER TABLE resumable_uploads ADD COLUMN IF NOT EXISTS hash_state BYTEA;
+
+-- register objects that were referenced before the registry existed
+INSERT INTO media_objects (owner_id, storage_key, purpose, mime_type, size_bytes)
+SELECT DISTINCT ON (i.image_key) i.user_id, i.image_key, 'avatar', 'application/octet-stream', 0
+FROM user_profile_images i
+ORDER BY i.image_key, i.id
+ON CONFLICT (storage_key) DO NOTHING;
+
+INSERT INTO media_objects (owner_id, storage_key, purpose, mime_type, size_bytes)
+SELECT DISTINCT ON (a.storage_key) m.sender_id, a.storage_key, 'message_attachment',
+       COALESCE(a.mime_type, 'application/octet-stream'), COALESCE(a.size_bytes, 0)
+FROM message_attachments a
+JOIN messages m ON m.id = a.message_id
+WHERE m.sender_id IS NOT NULL
+ORDER BY a.storage_key, a.id
+ON CONFLICT (storage_key) DO NOTHING;
+
+UPDATE media_objects o SET ref_count = r.n
+FROM (
+  SELECT storage_key, COUNT(*) AS n FROM (
+    SELECT image_key AS storage_key FROM user_profile_images
+    UNION ALL
+    SELECT storage_key FROM message_attachments
+  ) refs
+  GROUP BY storage_key
+) r
+WHERE o.storage_key = r.storage_key;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE resumable_uploads DROP COLUMN IF EXISTS hash_state;
+DROP INDEX IF EXISTS idx_media_objects_deleted;
+DELETE FROM media_objects WHERE deleted_at IS NOT NULL;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE media_objects DROP CONSTRAINT IF EXISTS media_objects_ref_count_check;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS ref_count;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS checksum;
+-- +goose StatementEnd
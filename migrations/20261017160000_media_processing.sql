@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS processing TEXT NOT NULL DEFAULT 'none'; -- none | pending | ready | failed
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS processing_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS processing_started_at TIMESTAMPTZ;       -- set while a worker holds the row
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS width INT;
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS height INT;
+ALTER TABLE media_objects ADD COLUMN IF NOT EXISTS placeholder TEXT;
+
+-- generate thumbnails for images uploaded before the pipeline existed
+UPDATE media_objects SET processing = 'pending'
+WHERE mime_type IN ('image/jpeg', 'image/png', 'image/gif');
+
+CREATE INDEX IF NOT EXISTS idx_media_objects_pending ON media_objects(id) WHERE processing = 'pending';
+CREATE INDEX IF NOT EXISTS idx_attachments_storage_key ON message_attachments(storage_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_attachments_storage_key;
+DROP INDEX IF EXISTS idx_media_objects_pending;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS placeholder;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS height;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS width;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS processing_started_at;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS processing_attempts;
+ALTER TABLE media_objects DROP COLUMN IF EXISTS processing;
+-- +goose StatementEnd
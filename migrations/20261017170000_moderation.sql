@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a NULL banned_until or restricted_until means until lifted by a moderator
+ALTER TABLE conversation_participants
+  ADD COLUMN IF NOT EXISTS banned_until     TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS restrictions     TEXT[] NOT NULL DEFAULT '{}', -- messages, media, links
+  ADD COLUMN IF NOT EXISTS restricted_until TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+  id              BIGSERIAL PRIMARY KEY,
+  conversation_id BIGINT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+  actor_id        BIGINT NOT NULL,
+  target_id       BIGINT NOT NULL,
+  action          TEXT NOT NULL, -- ban, unban, restrict, unrestrict, mute, unmute
+  restrictions    TEXT[] NOT NULL DEFAULT '{}',
+  until           TIMESTAMPTZ,
+  reason          TEXT,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_conv ON moderation_actions(conversation_id, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_moderation_actions_conv;
+DROP TABLE IF EXISTS moderation_actions;
+
+ALTER TABLE conversation_participants
+  DROP COLUMN IF EXISTS restricted_until,
+  DROP COLUMN IF EXISTS restrictions,
+  DROP COLUMN IF EXISTS banned_until;
+-- +goose StatementEnd
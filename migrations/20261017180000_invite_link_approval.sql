@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- users joining through an approval link land in join_requests instead of the chat
+ALTER TABLE invite_links
+  ADD COLUMN IF NOT EXISTS requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE invite_links
+  DROP COLUMN IF EXISTS requires_approval;
+-- +goose StatementEnd
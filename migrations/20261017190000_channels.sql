@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+-- member counts are kept up to date by a trigger, so large channels never have to be
+-- counted. They are spread over shards, so joins and leaves in a busy channel update
+-- different rows instead of all queueing on one row lock. The count is the sum of the shards.
+CREATE TABLE IF NOT EXISTS conversation_member_counts (
+  conversation_id BIGINT   NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+  shard           SMALLINT NOT NULL,
+  n               BIGINT   NOT NULL DEFAULT 0,
+  PRIMARY KEY (conversation_id, shard)
+);
+
+INSERT INTO conversation_member_counts (conversation_id, shard, n)
+SELECT cp.conversation_id, 0, COUNT(*)
+FROM conversation_participants cp
+WHERE cp.left_at IS NULL AND cp.role NOT IN ('left', 'banned')
+GROUP BY cp.conversation_id;
+
+CREATE OR REPLACE FUNCTION conversation_member_count() RETURNS TRIGGER AS $$
+DECLARE
+  was_active BOOLEAN := FALSE;
+  is_active  BOOLEAN := FALSE;
+  conv_id    BIGINT;
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    was_active := OLD.left_at IS NULL AND OLD.role NOT IN ('left', 'banned');
+    conv_id := OLD.conversation_id;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    is_active := NEW.left_at IS NULL AND NEW.role NOT IN ('left', 'banned');
+    conv_id := NEW.conversation_id;
+  END IF;
+
+  IF was_active <> is_active THEN
+    INSERT INTO conversation_member_counts (conversation_id, shard, n)
+    VALUES (conv_id, floor(random() * 16)::SMALLINT, CASE WHEN is_active THEN 1 ELSE -1 END)
+    ON CONFLICT (conversation_id, shard) DO UPDATE SET n = conversation_member_counts.n + EXCLUDED.n;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_conversation_member_count ON conversation_participants;
+CREATE TRIGGER trg_conversation_member_count
+  AFTER INSERT OR UPDATE OF role, left_at OR DELETE ON conversation_participants
+  FOR EACH ROW EXECUTE FUNCTION conversation_member_count();
+
+-- members are paged owner first, then admins, then everyone else by join time
+CREATE INDEX IF NOT EXISTS idx_participants_conv_page ON conversation_participants(
+  conversation_id, (CASE role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END), joined_at, user_id
+) WHERE left_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_participants_conv_role ON conversation_participants(conversation_id, role) WHERE left_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_participants_conv_role;
+DROP INDEX IF EXISTS idx_participants_conv_page;
+
+DROP TRIGGER IF EXISTS trg_conversation_member_count ON conversation_participants;
+DROP FUNCTION IF EXISTS conversation_member_count();
+DROP TABLE IF EXISTS conversation_member_counts;
+-- +goose StatementEnd